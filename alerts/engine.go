@@ -0,0 +1,246 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+	"github.com/JohannesKaufmann/News-API-go/dedupe"
+)
+
+// DefaultInterval is used for rules without an Interval.
+const DefaultInterval = 15 * time.Minute
+
+// Engine runs the rules and sends the matches to the sinks.
+type Engine struct {
+	// Client sends the requests. Default: the package level
+	// functions of news
+	Client *news.Client
+
+	Sinks []Sink
+
+	// Store remembers the delivered matches and the articles the
+	// rules already saw, so that they are only delivered once. Use
+	// a news.FileStore to remember them across restarts.
+	// Default: news.MemoryStore
+	Store news.SeenStore
+
+	// OnError gets called if a query or a sink failed.
+	OnError func(rule string, err error)
+
+	// now can be replaced in tests.
+	now func() time.Time
+
+	rules []*ruleState
+	mu    sync.Mutex
+
+	// sending contains the keys of the alerts that are being
+	// delivered right now.
+	sending map[string]bool
+}
+
+type ruleState struct {
+	rule     Rule
+	watcher  *news.Watcher
+	clusters *dedupe.Index
+}
+
+// NewEngine validates the rules and creates an engine that
+// delivers to the sinks.
+func NewEngine(rules []Rule, sinks ...Sink) (*Engine, error) {
+	e := &Engine{
+		Sinks:   sinks,
+		Store:   news.NewMemoryStore(),
+		now:     time.Now,
+		sending: make(map[string]bool),
+	}
+
+	names := make(map[string]bool)
+	for _, r := range rules {
+		if err := r.compile(); err != nil {
+			return nil, err
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("the rule name %q is used twice", r.Name)
+		}
+		names[r.Name] = true
+
+		e.rules = append(e.rules, &ruleState{
+			rule:     r,
+			clusters: &dedupe.Index{},
+		})
+	}
+	return e, nil
+}
+
+// watcher creates the watcher of the rule on the first use, so
+// that the Client can be set after NewEngine.
+func (e *Engine) watcher(s *ruleState) *news.Watcher {
+	if s.watcher != nil {
+		return s.watcher
+	}
+
+	interval := time.Duration(s.rule.Interval)
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	r := s.rule
+	s.watcher = &news.Watcher{
+		Interval: interval,
+		Store:    prefixStore{"article " + r.Name + " ", e.Store},
+		Query: func() ([]news.Article, *news.ResponseInfo, *news.Exception) {
+			if r.Everything != nil {
+				if e.Client != nil {
+					return e.Client.Everything(*r.Everything)
+				}
+				return news.Everything(*r.Everything)
+			}
+			if e.Client != nil {
+				return e.Client.TopHeadlines(*r.TopHeadlines)
+			}
+			return news.TopHeadlines(*r.TopHeadlines)
+		},
+		OnError: func(err *news.Exception) {
+			e.error(r.Name, err)
+		},
+	}
+	return s.watcher
+}
+
+// Poll runs every rule once and returns the delivered alerts.
+func (e *Engine) Poll() []Alert {
+	var alerts []Alert
+	for _, s := range e.rules {
+		_, err := e.watcher(s).PollEach(func(a news.Article) error {
+			alert, ok, err := e.handle(s, a)
+			if ok {
+				alerts = append(alerts, alert)
+			}
+			return err
+		})
+		if err != nil {
+			e.error(s.rule.Name, err)
+		}
+	}
+	return alerts
+}
+
+// Run polls every rule on its interval until stop is closed.
+func (e *Engine) Run(stop <-chan struct{}) {
+	var wg sync.WaitGroup
+	for _, s := range e.rules {
+		wg.Add(1)
+		go func(s *ruleState) {
+			defer wg.Done()
+			e.watcher(s).RunEach(stop, func(a news.Article) error {
+				_, _, err := e.handle(s, a)
+				return err
+			})
+		}(s)
+	}
+	wg.Wait()
+}
+
+// errNotSent keeps the article new in the watcher of the rule, so
+// that the next poll tries again.
+var errNotSent = errors.New("alerts: no sink accepted the alert")
+
+// handle filters a new article of the rule and delivers it
+// if it is a match that wasn't delivered before. The error
+// is set if the article should be handled again.
+func (e *Engine) handle(s *ruleState, a news.Article) (Alert, bool, error) {
+	if !s.rule.match(a) {
+		return Alert{}, false, nil
+	}
+
+	alert, key, ok, err := e.match(s, a)
+	if err != nil {
+		e.error(s.rule.Name, err)
+		return Alert{}, false, err
+	}
+	if !ok {
+		return Alert{}, false, nil
+	}
+	defer func() {
+		e.mu.Lock()
+		delete(e.sending, key)
+		e.mu.Unlock()
+	}()
+
+	// the sinks can be slow, so they are called without the lock
+	sent := len(e.Sinks) == 0
+	for _, sink := range e.Sinks {
+		if err := sink.Send(alert); err != nil {
+			e.error(s.rule.Name, err)
+		} else {
+			sent = true
+		}
+	}
+	// if every sink failed the article stays new and the
+	// next poll tries again
+	if !sent {
+		return Alert{}, false, errNotSent
+	}
+
+	if err := e.Store.MarkSeen(key); err != nil {
+		e.error(s.rule.Name, err)
+	}
+	return alert, true, nil
+}
+
+// match adds the article to the clusters of the rule and returns
+// the alert if the story is big enough and wasn't delivered yet.
+// The key is marked as sending until handle is done with it.
+func (e *Engine) match(s *ruleState, a news.Article) (Alert, string, bool, error) {
+	// the engine is used from multiple goroutines by Run
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cluster, _ := s.clusters.Add(a)
+	if len(cluster.Articles) < s.rule.MinClusterSize {
+		return Alert{}, "", false, nil
+	}
+
+	// a story is identified by its first article, so that it
+	// is only delivered once while the cluster grows
+	key := s.rule.Name + " " + cluster.Articles[0].ID()
+	if e.sending[key] {
+		return Alert{}, "", false, nil
+	}
+	delivered, err := e.Store.Seen(key)
+	if err != nil || delivered {
+		return Alert{}, "", false, err
+	}
+	e.sending[key] = true
+
+	return Alert{
+		Rule:        s.rule.Name,
+		Article:     cluster.Canonical,
+		ClusterSize: len(cluster.Articles),
+		Time:        e.now(),
+	}, key, true, nil
+}
+
+// prefixStore keeps the seen articles of the rules apart in the
+// shared store of the engine.
+type prefixStore struct {
+	prefix string
+	store  news.SeenStore
+}
+
+func (s prefixStore) Seen(url string) (bool, error) {
+	return s.store.Seen(s.prefix + url)
+}
+
+func (s prefixStore) MarkSeen(url string) error {
+	return s.store.MarkSeen(s.prefix + url)
+}
+
+func (e *Engine) error(rule string, err error) {
+	if e.OnError != nil {
+		e.OnError(rule, err)
+	}
+}
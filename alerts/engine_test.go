@@ -0,0 +1,188 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// apiServer returns the articles of the current poll.
+func apiServer(t *testing.T, polls [][]string) *news.Client {
+	var mu sync.Mutex
+	i := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var articles string
+		for j, title := range polls[i] {
+			if j > 0 {
+				articles += ","
+			}
+			articles += fmt.Sprintf(`{"title":%q,"url":"https://example.com/%d/%d","source":{"id":"src-%d"}}`, title, i, j, j)
+		}
+		i++
+		fmt.Fprintf(w, `{"status":"ok","totalResults":1,"articles":[%s]}`, articles)
+	}))
+	t.Cleanup(srv.Close)
+
+	return news.NewClient(news.Config{APIKey: "key", BaseURL: srv.URL})
+}
+
+func TestEngine_DeliverOnce(t *testing.T) {
+	client := apiServer(t, [][]string{
+		{"Example Corp buys a competitor", "The weather"},
+		{"Example Corp buys a competitor", "Example Corp buys a competitor"},
+	})
+
+	var sent []Alert
+	e, err := NewEngine([]Rule{{
+		Name:         "company",
+		Everything:   &news.EverythingOptions{Query: "example"},
+		TitlePattern: "Example Corp",
+	}}, SinkFunc(func(a Alert) error {
+		sent = append(sent, a)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Client = client
+
+	if alerts := e.Poll(); len(alerts) != 1 {
+		t.Fatal("expected one alert but got ", len(alerts))
+	}
+	// the same story from other sources is not delivered again
+	if alerts := e.Poll(); len(alerts) != 0 {
+		t.Fatal("expected no new alert but got ", len(alerts))
+	}
+	if len(sent) != 1 || sent[0].Rule != "company" {
+		t.Fatalf("unexpected alerts %+v", sent)
+	}
+}
+
+func TestEngine_MinClusterSize(t *testing.T) {
+	client := apiServer(t, [][]string{
+		{"Central bank raises the interest rates"},
+		{"Central bank raises the interest rates again"},
+		{"Central bank raises the interest rates today"},
+	})
+
+	e, _ := NewEngine([]Rule{{
+		Name:           "rates",
+		TopHeadlines:   &news.TopHeadlinesOptions{Country: "de"},
+		MinClusterSize: 2,
+	}})
+	e.Client = client
+
+	if alerts := e.Poll(); len(alerts) != 0 {
+		t.Fatal("expected no alert for a single article")
+	}
+	alerts := e.Poll()
+	if len(alerts) != 1 || alerts[0].ClusterSize != 2 {
+		t.Fatalf("expected an alert once the story has 2 articles but got %+v", alerts)
+	}
+	if alerts := e.Poll(); len(alerts) != 0 {
+		t.Fatal("expected the growing story not to be delivered again")
+	}
+}
+
+func TestEngine_SinkFailed(t *testing.T) {
+	// the same single article on every poll
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok","totalResults":1,"articles":[{"title":"Example Corp buys a competitor","url":"https://example.com/a"}]}`))
+	}))
+	defer srv.Close()
+
+	fail := true
+	var errs int
+	e, _ := NewEngine([]Rule{{
+		Name:       "company",
+		Everything: &news.EverythingOptions{Query: "example"},
+	}}, SinkFunc(func(a Alert) error {
+		if fail {
+			return errors.New("down")
+		}
+		return nil
+	}))
+	e.Client = news.NewClient(news.Config{APIKey: "key", BaseURL: srv.URL})
+	e.OnError = func(rule string, err error) { errs++ }
+
+	if alerts := e.Poll(); len(alerts) != 0 || errs != 1 {
+		t.Fatalf("expected no alert while the sink fails (%d errors)", errs)
+	}
+	// the article wasn't marked as seen, so the next poll tries again
+	fail = false
+	if alerts := e.Poll(); len(alerts) != 1 {
+		t.Fatal("expected the alert once the sink works but got ", len(alerts))
+	}
+	if alerts := e.Poll(); len(alerts) != 0 {
+		t.Fatal("expected the alert to be delivered only once")
+	}
+}
+
+// failingStore can't read the delivered alerts.
+type failingStore struct {
+	news.SeenStore
+}
+
+func (s failingStore) Seen(key string) (bool, error) {
+	if strings.HasPrefix(key, "article ") {
+		return s.SeenStore.Seen(key)
+	}
+	return false, errors.New("store down")
+}
+
+func TestEngine_OnErrorUnlocked(t *testing.T) {
+	client := apiServer(t, [][]string{{"Example Corp buys a competitor"}})
+
+	e, _ := NewEngine([]Rule{{
+		Name:       "company",
+		Everything: &news.EverythingOptions{Query: "example"},
+	}})
+	e.Client = client
+	e.Store = failingStore{news.NewMemoryStore()}
+
+	var errs int
+	e.OnError = func(rule string, err error) {
+		// deadlocks if OnError is called with the lock
+		e.mu.Lock()
+		errs++
+		e.mu.Unlock()
+	}
+	e.Poll()
+	if errs != 1 {
+		t.Fatal("expected the store error but got ", errs)
+	}
+}
+
+func TestEngine_Store(t *testing.T) {
+	client := apiServer(t, [][]string{{"Example Corp buys a competitor"}})
+
+	store := news.NewMemoryStore()
+	e, _ := NewEngine([]Rule{{
+		Name:       "company",
+		Everything: &news.EverythingOptions{Query: "example"},
+	}})
+	e.Client = client
+	e.Store = store
+	e.Poll()
+
+	seen, _ := store.Seen("article company https://example.com/0/0")
+	if !seen {
+		t.Fatal("expected the watcher of the rule to use the store of the engine")
+	}
+}
+
+func TestNewEngine_DuplicateName(t *testing.T) {
+	rule := Rule{Name: "a", Everything: &news.EverythingOptions{}}
+	if _, err := NewEngine([]Rule{rule, rule}); err == nil {
+		t.Fatal("expected an error for the duplicate name")
+	}
+}
@@ -0,0 +1,142 @@
+// Package alerts runs saved searches on a schedule and delivers the
+// matching articles to sinks like a file or an email (see
+// webhook.AlertSink for signed webhooks).
+//
+// A rule is a query of the news api plus local filters that the api
+// doesn't have: a regular expression for the title, an allowlist of
+// sources and a minimum number of sources that report the same story.
+// Every match is delivered only once.
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// Rule is a saved search.
+type Rule struct {
+	// Name identifies the rule in the alerts. It has to be unique.
+	Name string `json:"name"`
+
+	// Exactly one of the queries has to be set.
+	Everything   *news.EverythingOptions   `json:"everything,omitempty"`
+	TopHeadlines *news.TopHeadlinesOptions `json:"topHeadlines,omitempty"`
+
+	// TitlePattern is a regular expression the title has to match.
+	TitlePattern string `json:"titlePattern,omitempty"`
+
+	// Sources is an allowlist of source ids or names. If it is
+	// empty every source is allowed.
+	Sources []string `json:"sources,omitempty"`
+
+	// MinClusterSize is the number of articles about the same
+	// story (see the dedupe package) before the story is
+	// delivered. Default: 1 (every article)
+	MinClusterSize int `json:"minClusterSize,omitempty"`
+
+	// Interval is the time between two polls. Default: 15 minutes
+	Interval Duration `json:"interval,omitempty"`
+
+	title *regexp.Regexp
+}
+
+// Duration is a time.Duration that is written as "15m" in json.
+type Duration time.Duration
+
+// UnmarshalJSON reads a duration like "15m" or "1h30m".
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration like "15m0s".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// compile validates the rule and prepares the title pattern.
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("a rule needs a name")
+	}
+	if (r.Everything == nil) == (r.TopHeadlines == nil) {
+		return fmt.Errorf("rule %q: exactly one of everything and topHeadlines has to be set", r.Name)
+	}
+	if r.MinClusterSize < 0 {
+		return fmt.Errorf("rule %q: the min cluster size can't be negative", r.Name)
+	}
+	if r.Interval < 0 {
+		return fmt.Errorf("rule %q: the interval can't be negative", r.Name)
+	}
+
+	if r.TitlePattern != "" {
+		re, err := regexp.Compile(r.TitlePattern)
+		if err != nil {
+			return fmt.Errorf("rule %q: %v", r.Name, err)
+		}
+		r.title = re
+	}
+	return nil
+}
+
+// match checks the local filters.
+func (r *Rule) match(a news.Article) bool {
+	if r.title != nil && !r.title.MatchString(a.Title) {
+		return false
+	}
+	if len(r.Sources) == 0 {
+		return true
+	}
+	for _, s := range r.Sources {
+		if (a.Source.ID != "" && strings.EqualFold(s, a.Source.ID)) || strings.EqualFold(s, a.Source.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadRules reads the rules from a json file. The file contains
+// either an array of rules or an object with a "rules" array.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var rules []Rule
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &rules)
+	} else {
+		var file struct {
+			Rules []Rule `json:"rules"`
+		}
+		err = json.Unmarshal(data, &file)
+		rules = file.Rules
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,69 @@
+package alerts
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	ioutil.WriteFile(path, []byte(`{"rules": [{
+		"name": "company",
+		"everything": {"query": "\"Example Corp\"", "language": "de"},
+		"titlePattern": "(?i)example",
+		"sources": ["handelsblatt", "Die Zeit"],
+		"minClusterSize": 2,
+		"interval": "30m"
+	}]}`), 0644)
+
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rules[0]
+	if r.Everything.Query != `"Example Corp"` || r.Everything.Language != "de" {
+		t.Fatalf("unexpected query %+v", r.Everything)
+	}
+	if time.Duration(r.Interval) != 30*time.Minute || r.MinClusterSize != 2 {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+
+	tests := []struct {
+		article news.Article
+		match   bool
+	}{
+		{news.Article{Title: "Example Corp grows", Source: news.ArticleSource{ID: "handelsblatt"}}, true},
+		{news.Article{Title: "EXAMPLE Corp grows", Source: news.ArticleSource{Name: "die zeit"}}, true},
+		{news.Article{Title: "Something else", Source: news.ArticleSource{ID: "handelsblatt"}}, false},
+		{news.Article{Title: "Example Corp grows", Source: news.ArticleSource{ID: "bild"}}, false},
+	}
+	for _, test := range tests {
+		if r.match(test.article) != test.match {
+			t.Errorf("expected %v for %+v", test.match, test.article)
+		}
+	}
+}
+
+func TestLoadRules_Invalid(t *testing.T) {
+	rules := map[string]string{
+		`[{"everything": {}}]`: "needs a name",
+		`[{"name": "a"}]`:      "exactly one",
+		`[{"name": "a", "everything": {}, "topHeadlines": {}}]`:      "exactly one",
+		`[{"name": "a", "everything": {}, "titlePattern": "("}]`:     "missing closing",
+		`[{"name": "a", "everything": {}, "interval": "sometimes"}]`: "invalid duration",
+	}
+	for content, expected := range rules {
+		path := filepath.Join(t.TempDir(), "rules.json")
+		ioutil.WriteFile(path, []byte(content), 0644)
+
+		_, err := LoadRules(path)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected an error with %q for %s but got %v", expected, content, err)
+		}
+	}
+}
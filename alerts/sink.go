@@ -0,0 +1,142 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// Alert is a match of a rule.
+type Alert struct {
+	Rule    string       `json:"rule"`
+	Article news.Article `json:"article"`
+
+	// ClusterSize is the number of articles about the story
+	// when the alert was created.
+	ClusterSize int `json:"clusterSize"`
+
+	Time time.Time `json:"time"`
+}
+
+// Sink delivers alerts.
+type Sink interface {
+	Send(a Alert) error
+}
+
+// SinkFunc turns a func into a Sink.
+type SinkFunc func(a Alert) error
+
+// Send calls f.
+func (f SinkFunc) Send(a Alert) error {
+	return f(a)
+}
+
+// WriterSink writes one line per alert, for example to os.Stdout.
+type WriterSink struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+// Send writes the alert.
+func (s *WriterSink) Send(a Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.W, "[%s] %s (%s) %s\n", a.Rule, a.Article.Title, a.Article.Source.Name, a.Article.URL)
+	return err
+}
+
+// FileSink appends the alerts as json lines to a file.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens (or creates) the file.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Send appends the alert.
+func (s *FileSink) Send(a Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return json.NewEncoder(s.file).Encode(a)
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// MailSender sends an email. SMTPSender implements it with net/smtp,
+// other implementations can use the api of a mail service.
+type MailSender interface {
+	SendMail(to []string, subject, body string) error
+}
+
+// SMTPSender sends emails over SMTP.
+type SMTPSender struct {
+	// Addr is the host and port of the server, for
+	// example "smtp.example.com:587".
+	Addr string
+	Auth smtp.Auth
+	From string
+}
+
+// SendMail sends a plain text email.
+func (s SMTPSender) SendMail(to []string, subject, body string) error {
+	return smtp.SendMail(s.Addr, s.Auth, s.From, to, s.message(to, subject, body))
+}
+
+func (s SMTPSender) message(to []string, subject, body string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	// titles often have umlauts or quotes, a header must be ascii
+	subject = strings.NewReplacer("\r", "", "\n", "").Replace(subject)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	return msg.Bytes()
+}
+
+// EmailSink sends an email for every alert.
+type EmailSink struct {
+	Sender MailSender
+	To     []string
+}
+
+// Send sends the email.
+func (s EmailSink) Send(a Alert) error {
+	subject := fmt.Sprintf("[%s] %s", a.Rule, a.Article.Title)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\n\n", a.Article.Title)
+	if a.Article.Description != "" {
+		fmt.Fprintf(&body, "%s\n\n", a.Article.Description)
+	}
+	fmt.Fprintf(&body, "Source: %s\n", a.Article.Source.Name)
+	if a.ClusterSize > 1 {
+		fmt.Fprintf(&body, "Reported in %d articles\n", a.ClusterSize)
+	}
+	fmt.Fprintf(&body, "%s\n", a.Article.URL)
+
+	return s.Sender.SendMail(s.To, subject, body.String())
+}
@@ -0,0 +1,92 @@
+package alerts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+var testAlert = Alert{
+	Rule: "company",
+	Article: news.Article{
+		Title:  "Example Corp buys a competitor",
+		URL:    "https://example.com/1",
+		Source: news.ArticleSource{Name: "Handelsblatt"},
+	},
+	ClusterSize: 3,
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	(&WriterSink{W: &buf}).Send(testAlert)
+
+	if buf.String() != "[company] Example Corp buys a competitor (Handelsblatt) https://example.com/1\n" {
+		t.Fatal("unexpected output ", buf.String())
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	s, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Send(testAlert)
+	s.Send(testAlert)
+	s.Close()
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var a Alert
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil || a.Rule != "company" {
+			t.Fatal("unexpected line ", scanner.Text())
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Fatal("expected 2 lines but got ", lines)
+	}
+}
+
+type fakeMail struct {
+	to            []string
+	subject, body string
+}
+
+func (m *fakeMail) SendMail(to []string, subject, body string) error {
+	m.to, m.subject, m.body = to, subject, body
+	return nil
+}
+
+func TestEmailSink(t *testing.T) {
+	mail := &fakeMail{}
+	EmailSink{Sender: mail, To: []string{"editors@example.com"}}.Send(testAlert)
+
+	if mail.subject != "[company] Example Corp buys a competitor" {
+		t.Fatal("unexpected subject ", mail.subject)
+	}
+	if !strings.Contains(mail.body, "Reported in 3 articles") || !strings.Contains(mail.body, testAlert.Article.URL) {
+		t.Fatal("unexpected body ", mail.body)
+	}
+}
+
+func TestSMTPSender_Subject(t *testing.T) {
+	s := SMTPSender{From: "alerts@example.com"}
+	msg := string(s.message([]string{"editors@example.com"}, "[company] Übernahme\r\nBcc: x@example.com", "text"))
+
+	if !strings.Contains(msg, "Subject: =?utf-8?q?[company]_=C3=9Cbernahme") {
+		t.Fatal("expected an encoded subject but got ", msg)
+	}
+	if strings.Contains(msg, "\r\nBcc:") {
+		t.Fatal("expected no injected header but got ", msg)
+	}
+}
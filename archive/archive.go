@@ -0,0 +1,349 @@
+// Package archive keeps articles from the news api in a local file
+// so that they are still available after they dropped out of the
+// history window of the plan.
+//
+// The archive is an append-only log of json lines that gets loaded
+// into memory when it is opened. No database server is needed.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// Record is an archived article.
+type Record struct {
+	// Key is the canonical url of the article (news.NormalizeURL).
+	// It is used to dedupe the articles.
+	Key string `json:"key"`
+
+	Article news.Article `json:"article"`
+
+	// The 2-letter ISO-639-1 code of the language. It is taken from
+	// the query that found the article and can be empty.
+	Language string `json:"language,omitempty"`
+
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+
+	// Queries contains every query that found the article,
+	// for example "everything?language=de&q=bitcoin".
+	Queries []string `json:"queries"`
+}
+
+// PublishedAt parses the date of the article.
+func (r Record) PublishedAt() time.Time {
+	t, _ := time.Parse(time.RFC3339, r.Article.PublishedAt)
+	return t
+}
+
+// Archive is a file based article store.
+type Archive struct {
+	mu      sync.RWMutex
+	path    string
+	file    *os.File
+	records map[string]*Record
+
+	// now can be replaced in tests.
+	now func() time.Time
+}
+
+// Open opens (or creates) the archive at path.
+func Open(path string) (*Archive, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		path:    path,
+		file:    file,
+		records: make(map[string]*Record),
+		now:     time.Now,
+	}
+
+	// the file can contain multiple versions of a record,
+	// the last one wins.
+	versions := make(map[string]*Record)
+	var keys []string
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			file.Close()
+			return nil, readErr
+		}
+		partial := readErr == io.EOF && len(line) > 0
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var r Record
+			err := json.Unmarshal(line, &r)
+			if err != nil && partial {
+				// the last write didn't finish. It is cut off,
+				// otherwise the next record would be appended
+				// to the same line.
+				if err := file.Truncate(offset); err != nil {
+					file.Close()
+					return nil, err
+				}
+				break
+			}
+			if err != nil {
+				file.Close()
+				return nil, err
+			}
+			if partial {
+				if _, err := file.Write([]byte("\n")); err != nil {
+					file.Close()
+					return nil, err
+				}
+			}
+
+			if _, ok := versions[r.Key]; !ok {
+				keys = append(keys, r.Key)
+			}
+			versions[r.Key] = &r
+		}
+		offset += int64(len(line))
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	// the key is computed again, so that older archives use the
+	// current rules of news.NormalizeURL. Records that end up with
+	// the same key are merged.
+	for _, key := range keys {
+		r := versions[key]
+		if r.Article.URL != "" {
+			r.Key = r.Article.CanonicalURL()
+		}
+		if existing, ok := a.records[r.Key]; ok {
+			existing.merge(r)
+			continue
+		}
+		a.records[r.Key] = r
+	}
+
+	return a, nil
+}
+
+// Close closes the underlying file.
+func (a *Archive) Close() error {
+	return a.file.Close()
+}
+
+// Len returns the number of archived articles.
+func (a *Archive) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return len(a.records)
+}
+
+// Get returns the archived article with the url.
+func (a *Archive) Get(articleURL string) (Record, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	r, ok := a.records[news.NormalizeURL(articleURL)]
+	if !ok {
+		return Record{}, false
+	}
+	return r.copy(), true
+}
+
+// Ingest adds the articles to the archive. Articles that are already
+// archived get their LastSeen and Queries updated.
+func (a *Archive) Ingest(query, language string, articles []news.Article) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now().UTC()
+	enc := json.NewEncoder(a.file)
+
+	for _, article := range articles {
+		key := article.CanonicalURL()
+		if key == "" {
+			continue
+		}
+
+		r, ok := a.records[key]
+		if !ok {
+			r = &Record{
+				Key:       key,
+				FirstSeen: now,
+			}
+			a.records[key] = r
+		}
+
+		// always keep the newest version of the article
+		r.Article = article
+		r.LastSeen = now
+		if r.Language == "" {
+			r.Language = language
+		}
+		if query != "" && !contains(r.Queries, query) {
+			r.Queries = append(r.Queries, query)
+		}
+
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Hook can be used as news.ArticlesHook to archive every result:
+//
+//	news.ArticlesHook = a.Hook
+func (a *Archive) Hook(endpoint string, query url.Values, articles []news.Article) {
+	err := a.Ingest(endpoint+"?"+query.Encode(), query.Get("language"), articles)
+	if err != nil {
+		log.Println("[news archive] error: could not archive the articles:", err)
+	}
+}
+
+// Filter narrows down the result of Find. Empty fields match
+// every article.
+type Filter struct {
+	// From and To limit the PublishedAt date (inclusive).
+	From time.Time
+	To   time.Time
+
+	// Sources contains the ids or names of the sources.
+	Sources []string
+
+	Language string
+}
+
+func (f Filter) match(r *Record) bool {
+	if !f.From.IsZero() || !f.To.IsZero() {
+		published := r.PublishedAt()
+		if published.IsZero() {
+			return false
+		}
+		if !f.From.IsZero() && published.Before(f.From) {
+			return false
+		}
+		if !f.To.IsZero() && published.After(f.To) {
+			return false
+		}
+	}
+	if len(f.Sources) > 0 && !contains(f.Sources, r.Article.Source.ID) && !contains(f.Sources, r.Article.Source.Name) {
+		return false
+	}
+	if f.Language != "" && f.Language != r.Language {
+		return false
+	}
+	return true
+}
+
+// Find returns the archived articles that match the filter,
+// the newest first.
+func (a *Archive) Find(f Filter) []Record {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var result []Record
+	for _, r := range a.records {
+		if f.match(r) {
+			result = append(result, r.copy())
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		ti, tj := result[i].PublishedAt(), result[j].PublishedAt()
+		if ti.Equal(tj) {
+			return result[i].Key < result[j].Key
+		}
+		return ti.After(tj)
+	})
+	return result
+}
+
+// Compact rewrites the file so that it only contains the newest
+// version of every record.
+func (a *Archive) Compact() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// the temporary file is used for the appends after the
+	// rename, so the archive never ends up without an open file.
+	tmp, err := os.OpenFile(a.path+".tmp", os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, r := range a.records {
+		if err := enc.Encode(r); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(a.path+".tmp", a.path); err != nil {
+		tmp.Close()
+		os.Remove(a.path + ".tmp")
+		return err
+	}
+
+	old := a.file
+	a.file = tmp
+	return old.Close()
+}
+
+// merge adds an older version of the record that had another key.
+func (r *Record) merge(o *Record) {
+	if o.FirstSeen.Before(r.FirstSeen) {
+		r.FirstSeen = o.FirstSeen
+	}
+	if o.LastSeen.After(r.LastSeen) {
+		r.LastSeen = o.LastSeen
+		r.Article = o.Article
+	}
+	if r.Language == "" {
+		r.Language = o.Language
+	}
+	for _, q := range o.Queries {
+		if !contains(r.Queries, q) {
+			r.Queries = append(r.Queries, q)
+		}
+	}
+}
+
+func (r *Record) copy() Record {
+	c := *r
+	c.Queries = append([]string(nil), r.Queries...)
+	return c
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package archive
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+var articles = []news.Article{
+	{
+		Source:      news.ArticleSource{ID: "bbc-news", Name: "BBC News"},
+		Title:       "Title 1",
+		URL:         "http://www.bbc.co.uk/news/1",
+		PublishedAt: "2017-12-18T16:27:39Z",
+	},
+	{
+		Source:      news.ArticleSource{Name: "Sueddeutsche.de"},
+		Title:       "Title 2",
+		URL:         "http://www.sueddeutsche.de/2",
+		PublishedAt: "2017-11-15T17:49:05Z",
+	},
+}
+
+func openTestArchive(t *testing.T) (*Archive, string) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, path
+}
+
+func TestArchive_Dedupe(t *testing.T) {
+	a, _ := openTestArchive(t)
+	defer a.Close()
+
+	first := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return first }
+	if err := a.Ingest("everything?q=a", "en", articles); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same article with tracking parameters and a trailing slash
+	again := []news.Article{articles[0]}
+	again[0].URL = "HTTP://WWW.BBC.CO.UK/news/1/?utm_source=twitter#comments"
+
+	second := first.Add(time.Hour)
+	a.now = func() time.Time { return second }
+	if err := a.Ingest("top-headlines?country=gb", "", again); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Len() != 2 {
+		t.Fatal("expected 2 articles but got ", a.Len())
+	}
+
+	r, ok := a.Get(articles[0].URL)
+	if !ok {
+		t.Fatal("expected the article to be archived")
+	}
+	if !r.FirstSeen.Equal(first) || !r.LastSeen.Equal(second) {
+		t.Fatalf("unexpected first/last seen: %v %v", r.FirstSeen, r.LastSeen)
+	}
+	if len(r.Queries) != 2 {
+		t.Fatal("expected both queries but got ", r.Queries)
+	}
+	if r.Language != "en" {
+		t.Fatal("expected the language of the first query but got ", r.Language)
+	}
+}
+
+func TestArchive_Reopen(t *testing.T) {
+	a, path := openTestArchive(t)
+	a.Ingest("everything?q=a", "en", articles)
+	a.Ingest("everything?q=b", "en", articles[:1])
+	a.Close()
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if a.Len() != 2 {
+		t.Fatal("expected 2 articles but got ", a.Len())
+	}
+	r, _ := a.Get(articles[0].URL)
+	if len(r.Queries) != 2 {
+		t.Fatal("expected the newest version of the record but got ", r.Queries)
+	}
+
+	if err := a.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	a.Ingest("everything?q=c", "en", articles[1:])
+	a.Close()
+
+	a, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if a.Len() != 2 {
+		t.Fatal("expected 2 articles after compacting but got ", a.Len())
+	}
+}
+
+func TestArchive_PartialLine(t *testing.T) {
+	a, path := openTestArchive(t)
+	a.Ingest("everything?q=a", "en", articles[:1])
+	a.Close()
+
+	// a write that didn't finish
+	data, _ := ioutil.ReadFile(path)
+	ioutil.WriteFile(path, append(data, `{"key":"http://www.sue`...), 0644)
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Ingest("everything?q=b", "de", articles[1:])
+	a.Close()
+
+	a, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if a.Len() != 2 {
+		t.Fatal("expected 2 articles but got ", a.Len())
+	}
+}
+
+func TestArchive_MergeKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+
+	// two records that older rules of news.NormalizeURL kept apart
+	first := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	old := articles[0]
+	old.URL = "HTTP://WWW.BBC.CO.UK/news/1/?utm_source=twitter"
+	data := `{"key":"a","article":{"url":"` + articles[0].URL + `","title":"new"},"firstSeen":"2018-01-02T00:00:00Z","lastSeen":"2018-01-03T00:00:00Z","queries":["q=b"]}
+{"key":"b","article":{"url":"` + old.URL + `","title":"old"},"language":"en","firstSeen":"2018-01-01T00:00:00Z","lastSeen":"2018-01-02T00:00:00Z","queries":["q=a"]}
+`
+	ioutil.WriteFile(path, []byte(data), 0644)
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if a.Len() != 1 {
+		t.Fatal("expected the records to be merged but got ", a.Len())
+	}
+	r, _ := a.Get(articles[0].URL)
+	if !r.FirstSeen.Equal(first) || len(r.Queries) != 2 || r.Language != "en" || r.Article.Title != "new" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+}
+
+func TestArchive_Find(t *testing.T) {
+	a, _ := openTestArchive(t)
+	defer a.Close()
+
+	a.Ingest("everything?q=a", "en", articles[:1])
+	a.Ingest("everything?q=b", "de", articles[1:])
+
+	all := a.Find(Filter{})
+	if len(all) != 2 || all[0].Article.Title != "Title 1" {
+		t.Fatal("expected every article with the newest first but got ", all)
+	}
+
+	december := a.Find(Filter{
+		From: time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC),
+	})
+	if len(december) != 1 || december[0].Article.Title != "Title 1" {
+		t.Fatal("expected only the article from december but got ", december)
+	}
+
+	bySource := a.Find(Filter{Sources: []string{"Sueddeutsche.de"}})
+	if len(bySource) != 1 || bySource[0].Article.Title != "Title 2" {
+		t.Fatal("expected only the article from the source but got ", bySource)
+	}
+
+	byLanguage := a.Find(Filter{Language: "en"})
+	if len(byLanguage) != 1 || byLanguage[0].Article.Title != "Title 1" {
+		t.Fatal("expected only the english article but got ", byLanguage)
+	}
+}
+
+func TestArchive_Hook(t *testing.T) {
+	a, _ := openTestArchive(t)
+	defer a.Close()
+
+	query := url.Values{}
+	query.Set("q", "bitcoin")
+	query.Set("language", "de")
+	a.Hook("everything", query, articles)
+
+	r, ok := a.Get(articles[1].URL)
+	if !ok {
+		t.Fatal("expected the article to be archived")
+	}
+	if r.Language != "de" || r.Queries[0] != "everything?language=de&q=bitcoin" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+}
@@ -0,0 +1,109 @@
+package news
+
+import "sync"
+
+// BatchResult is the result for one of the options passed to Run.
+type BatchResult struct {
+	// Options is the options struct this result belongs to.
+	Options interface{}
+
+	// either Articles (TopHeadlines, Everything) or Sources
+	Articles []Article
+	Sources  []Source
+
+	Info *ResponseInfo
+	Err  *Exception
+}
+
+// BatchStats combines the ResponseInfo of every request.
+type BatchStats struct {
+	Requests     int
+	Failed       int
+	Cached       int
+	TotalResults int
+}
+
+// Batch runs many queries at once, for example the top headlines
+// for every country.
+type Batch struct {
+	// Client sends the requests. Default: the package level settings
+	Client *Client
+
+	// Concurrency is the maximum number of requests that run at
+	// the same time. Default: 4
+	Concurrency int
+}
+
+// Run runs the queries. Every element of opts has to be a
+// TopHeadlinesOptions, EverythingOptions or SourcesOptions.
+//
+// The results have the same order as opts. A failed query does
+// not stop the others, its error is in BatchResult.Err. Every
+// request goes through the limiter and retry policy of the client.
+func (b Batch) Run(opts []interface{}) ([]BatchResult, BatchStats) {
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	client := b.Client
+	if client == nil {
+		client = defaultClient()
+	}
+
+	results := make([]BatchResult, len(opts))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = runOne(client, opts[i])
+			}
+		}()
+	}
+	for i := range opts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var stats BatchStats
+	for _, r := range results {
+		stats.Requests++
+		if r.Err != nil {
+			stats.Failed++
+			continue
+		}
+		if r.Info != nil {
+			if r.Info.Cached {
+				stats.Cached++
+			}
+			stats.TotalResults += r.Info.TotalResults
+		}
+	}
+
+	return results, stats
+}
+
+func runOne(c *Client, opt interface{}) BatchResult {
+	r := BatchResult{Options: opt}
+
+	switch o := opt.(type) {
+	case TopHeadlinesOptions:
+		r.Articles, r.Info, r.Err = c.TopHeadlines(o)
+	case EverythingOptions:
+		r.Articles, r.Info, r.Err = c.Everything(o)
+	case SourcesOptions:
+		r.Sources, r.Info, r.Err = c.Sources(o)
+	default:
+		r.Err = &Exception{
+			Code:    "[batch]",
+			Message: "unsupported options type, use TopHeadlinesOptions, EverythingOptions or SourcesOptions",
+		}
+	}
+
+	return r
+}
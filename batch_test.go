@@ -0,0 +1,63 @@
+package news
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatch_PartialFailure(t *testing.T) {
+	t.Parallel()
+
+	var running, maxRunning int32
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		json := `{"status":"ok","totalResults":1,"articles":[{"title":"` + req.URL.Query().Get("country") + `"}]}`
+		if req.URL.Query().Get("country") == "xx" {
+			json = `{"status":"error","code":"parameterInvalid","message":"invalid country"}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
+
+	var opts []interface{}
+	for _, country := range []string{"de", "us", "xx", "gb", "fr", "it"} {
+		opts = append(opts, TopHeadlinesOptions{Country: country})
+	}
+	opts = append(opts, "not an options struct")
+
+	results, stats := Batch{Client: c, Concurrency: 2}.Run(opts)
+
+	if len(results) != 7 {
+		t.Fatal("expected 7 results but got ", len(results))
+	}
+	if results[0].Articles[0].Title != "de" || results[5].Articles[0].Title != "it" {
+		t.Fatal("expected the results in the same order as the options")
+	}
+	if results[2].Err == nil || results[2].Err.Code != "parameterInvalid" {
+		t.Fatal("expected an error for the invalid country but got ", results[2].Err)
+	}
+	if results[6].Err == nil || results[6].Err.Code != "[batch]" {
+		t.Fatal("expected an error for the unsupported options but got ", results[6].Err)
+	}
+	if stats.Requests != 7 || stats.Failed != 2 || stats.TotalResults != 5 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if maxRunning > 2 {
+		t.Fatal("expected at most 2 requests at the same time but got ", maxRunning)
+	}
+}
@@ -0,0 +1,252 @@
+package news
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// URLRules configures how NormalizeURL turns the different forms of
+// an article url into one. Lowercasing the scheme and host, removing
+// the default port, the fragment and a trailing slash and sorting
+// the query parameters is always done.
+type URLRules struct {
+	// StripParams are query parameters that are removed. A name
+	// ending with "*" removes every parameter with that prefix.
+	StripParams []string
+
+	// UnwrapAMP turns AMP urls into the normal article url. It
+	// handles the AMP cache of Google, "amp." hosts, a leading
+	// "/amp/" or trailing "/amp" path segment, ".amp", ".amp.html"
+	// and the "amp" query parameters.
+	UnwrapAMP bool
+
+	// FoldHosts removes the "m.", "mobile." and "www." prefix of
+	// the host so that the mobile and desktop versions are the same.
+	FoldHosts bool
+
+	// UpgradeScheme changes "http" to "https".
+	UpgradeScheme bool
+}
+
+// CanonicalURLRules are the rules used by NormalizeURL and
+// Article.CanonicalURL. They are read once by the first call, so
+// change them before or use SetCanonicalURLRules afterwards.
+var CanonicalURLRules = URLRules{
+	StripParams: []string{
+		"utm_*", "fbclid", "gclid", "dclid", "msclkid", "igshid",
+		"mc_cid", "mc_eid", "_ga", "ocid", "cmpid", "ns_*", "at_*",
+		"__twitter_impression",
+	},
+	UnwrapAMP:     true,
+	FoldHosts:     true,
+	UpgradeScheme: true,
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   *URLRules
+)
+
+// SetCanonicalURLRules replaces the rules of NormalizeURL. The ids of
+// articles change with the rules, so set them before ids are stored.
+func SetCanonicalURLRules(r URLRules) {
+	r.StripParams = append([]string(nil), r.StripParams...)
+	rulesMu.Lock()
+	rules = &r
+	rulesMu.Unlock()
+}
+
+// canonicalURLRules returns the rules of NormalizeURL. They are
+// copied from CanonicalURLRules on first use.
+func canonicalURLRules() *URLRules {
+	rulesMu.RLock()
+	r := rules
+	rulesMu.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if rules == nil {
+		r := CanonicalURLRules
+		r.StripParams = append([]string(nil), r.StripParams...)
+		rules = &r
+	}
+	return rules
+}
+
+// NormalizeURL returns the canonical form of the url using the
+// CanonicalURLRules. Urls that can't be parsed are only trimmed.
+func NormalizeURL(rawURL string) string {
+	return canonicalURLRules().Normalize(rawURL)
+}
+
+// CanonicalURL returns the normalized url of the article.
+func (a Article) CanonicalURL() string {
+	return NormalizeURL(a.URL)
+}
+
+// ID returns a stable identifier for the article: the first 16 bytes
+// of the sha256 hash of the canonical url as 32 hex characters. It
+// is empty if the article has no url.
+func (a Article) ID() string {
+	canonical := a.CanonicalURL()
+	if canonical == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Normalize returns the canonical form of the url using the rules.
+func (r URLRules) Normalize(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	if r.UnwrapAMP {
+		if inner := unwrapAMPCache(u); inner != nil {
+			u = inner
+		}
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (port == "80" && u.Scheme == "http") || (port == "443" && u.Scheme == "https") {
+		port = ""
+	}
+	if r.UpgradeScheme && u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	if r.UnwrapAMP {
+		host = trimHostPrefix(host, "amp.")
+	}
+	if r.FoldHosts {
+		for _, prefix := range []string{"m.", "mobile.", "www."} {
+			host = trimHostPrefix(host, prefix)
+		}
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	u.Host = host
+
+	u.Fragment = ""
+	u.User = nil
+
+	// the path is changed in its escaped form, so that for
+	// example "%2F" is not turned into a "/"
+	path := u.EscapedPath()
+	if r.UnwrapAMP {
+		path = unwrapAMPPath(path)
+	}
+	path = strings.TrimSuffix(path, "/")
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		u.Path, u.RawPath = unescaped, path
+	}
+
+	query := u.Query()
+	for key := range query {
+		if r.strip(key) {
+			query.Del(key)
+		}
+		if r.UnwrapAMP && (key == "amp" || (key == "outputType" && query.Get(key) == "amp")) {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func (r URLRules) strip(param string) bool {
+	param = strings.ToLower(param)
+	for _, p := range r.StripParams {
+		p = strings.ToLower(p)
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(param, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if param == p {
+			return true
+		}
+	}
+	return false
+}
+
+// trimHostPrefix removes the prefix if a domain with at least
+// two labels is left, so that "m.co" stays the same.
+func trimHostPrefix(host, prefix string) string {
+	rest := strings.TrimPrefix(host, prefix)
+	if rest == host || !strings.Contains(rest, ".") {
+		return host
+	}
+	return rest
+}
+
+// unwrapAMPCache returns the original url of an article that is
+// served by the AMP cache of Google, for example
+// https://www-example-com.cdn.ampproject.org/c/s/www.example.com/news/1
+// or https://www.google.com/amp/s/www.example.com/news/1
+func unwrapAMPCache(u *url.URL) *url.URL {
+	host := strings.ToLower(u.Hostname())
+
+	var rest string
+	switch {
+	case strings.HasSuffix(host, ".cdn.ampproject.org"):
+		// the first part is "c" (content), "v" (viewer)
+		// or "i" (image)
+		parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+		if len(parts) != 2 {
+			return nil
+		}
+		rest = parts[1]
+	case (host == "google.com" || strings.HasPrefix(host, "www.google.")) && strings.HasPrefix(u.Path, "/amp/"):
+		rest = strings.TrimPrefix(u.Path, "/amp/")
+	default:
+		return nil
+	}
+
+	// "s/" means that the original url uses https
+	scheme := "http"
+	if strings.HasPrefix(rest, "s/") {
+		scheme = "https"
+		rest = strings.TrimPrefix(rest, "s/")
+	}
+
+	inner, err := url.Parse(scheme + "://" + rest)
+	if err != nil || inner.Host == "" {
+		return nil
+	}
+	inner.RawQuery = u.RawQuery
+	return inner
+}
+
+// unwrapAMPPath removes the known AMP forms from the path:
+// "/amp/news/1", "/news/1/amp", "/news/1.amp" and "/news/1.amp.html".
+// An "amp" segment in the middle ("/tags/amp/news") is kept.
+func unwrapAMPPath(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".amp.html"):
+		return strings.TrimSuffix(path, ".amp.html") + ".html"
+	case strings.HasSuffix(path, ".amp"):
+		return strings.TrimSuffix(path, ".amp")
+	}
+
+	trimmed := strings.TrimSuffix(path, "/")
+	if i := strings.LastIndex(trimmed, "/"); i > 0 && strings.EqualFold(trimmed[i+1:], "amp") {
+		return trimmed[:i]
+	}
+	if len(path) > len("/amp/") && strings.EqualFold(path[:len("/amp/")], "/amp/") {
+		return path[len("/amp"):]
+	}
+	return path
+}
@@ -0,0 +1,80 @@
+package news
+
+import (
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, out string
+	}{
+		{"https://example.com/news/1", "https://example.com/news/1"},
+		{"  HTTP://WWW.Example.COM:80/news/1/  ", "https://example.com/news/1"},
+		{"https://example.com/news/1?utm_source=twitter&utm_medium=social&id=5#comments", "https://example.com/news/1?id=5"},
+		{"https://example.com/news/1?b=2&a=1&fbclid=abc", "https://example.com/news/1?a=1&b=2"},
+		{"https://m.example.com/news/1", "https://example.com/news/1"},
+		{"https://mobile.example.co.uk/news/1", "https://example.co.uk/news/1"},
+		{"https://amp.example.com/news/1", "https://example.com/news/1"},
+		{"https://example.com/amp/news/1", "https://example.com/news/1"},
+		{"https://example.com/news/1/amp", "https://example.com/news/1"},
+		{"https://example.com/news/1.amp.html", "https://example.com/news/1.html"},
+		{"https://example.com/news/1?outputType=amp", "https://example.com/news/1"},
+		{"https://www-example-com.cdn.ampproject.org/c/s/www.example.com/news/1", "https://example.com/news/1"},
+		{"https://www.google.com/amp/s/www.example.com/news/1/amp/", "https://example.com/news/1"},
+		{"https://example.com:8443/news/1", "https://example.com:8443/news/1"},
+		{"https://m.co/news", "https://m.co/news"},
+		{"https://example.com/files/a%2Fb/1", "https://example.com/files/a%2Fb/1"},
+		{"https://example.com/tags/amp/news", "https://example.com/tags/amp/news"},
+		{"https://example.com/news/1.amp", "https://example.com/news/1"},
+		{"https://example.com/amp", "https://example.com/amp"},
+		{"not a url", "not a url"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if out := NormalizeURL(test.in); out != test.out {
+			t.Errorf("NormalizeURL(%q) = %q, expected %q", test.in, out, test.out)
+		}
+	}
+}
+
+func TestURLRules_Normalize(t *testing.T) {
+	t.Parallel()
+
+	rules := URLRules{StripParams: []string{"ref"}}
+
+	out := rules.Normalize("http://m.example.com/amp/news/1?ref=x&utm_source=y")
+	if out != "http://m.example.com/amp/news/1?utm_source=y" {
+		t.Fatal("expected only the configured rules to be used but got ", out)
+	}
+}
+
+func TestSetCanonicalURLRules(t *testing.T) {
+	defer SetCanonicalURLRules(CanonicalURLRules)
+
+	SetCanonicalURLRules(URLRules{})
+	if out := NormalizeURL("https://www.example.com/news/1?utm_source=x"); out != "https://www.example.com/news/1?utm_source=x" {
+		t.Fatal("expected the new rules to be used but got ", out)
+	}
+}
+
+func TestArticle_ID(t *testing.T) {
+	t.Parallel()
+
+	a := Article{URL: "https://www.example.com/news/1?utm_source=twitter"}
+	b := Article{URL: "http://m.example.com/news/1/"}
+
+	if a.CanonicalURL() != "https://example.com/news/1" {
+		t.Fatal("unexpected canonical url ", a.CanonicalURL())
+	}
+	if a.ID() != b.ID() || len(a.ID()) != 32 {
+		t.Fatal("expected the same id for the same canonical url but got ", a.ID(), " and ", b.ID())
+	}
+	if a.ID() == (Article{URL: "https://example.com/news/2"}).ID() {
+		t.Fatal("expected a different id for a different url")
+	}
+	if (Article{}).ID() != "" {
+		t.Fatal("expected no id without an url")
+	}
+}
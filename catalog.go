@@ -0,0 +1,172 @@
+package news
+
+import (
+	"fmt"
+	"strings"
+)
+
+//go:generate go run ./internal/gensources -fixture testdata/sources.json -o sources_snapshot.go
+
+// The sources catalogue is compiled into the package, so that a
+// source can be looked up without a request. It is a snapshot and
+// can miss sources that were added to the api afterwards.
+//
+// go generate builds it offline from testdata/sources.json, the saved
+// response of the sources endpoint. Refresh both from the api with
+//
+//	go run ./internal/gensources -key <api key> -fixture testdata/sources.json
+//
+// The saved response still only has a part of the catalogue, so
+// refresh it before relying on the snapshot.
+
+// sourcesByID indexes the snapshot by id.
+var sourcesByID = func() map[string]Source {
+	m := make(map[string]Source, len(sourcesSnapshot))
+	for _, s := range sourcesSnapshot {
+		m[s.ID] = s
+	}
+	return m
+}()
+
+// KnownSources returns every source of the snapshot, sorted by id.
+func KnownSources() []Source {
+	return append([]Source(nil), sourcesSnapshot...)
+}
+
+// SourceByID looks up the source in the snapshot.
+func SourceByID(id string) (Source, bool) {
+	s, ok := sourcesByID[strings.ToLower(strings.TrimSpace(id))]
+	return s, ok
+}
+
+// SourcesByCountry returns the sources of the snapshot for the
+// 2-letter ISO 3166-1 code of the country, for example "de".
+func SourcesByCountry(country string) []Source {
+	return filterSources(func(s Source) bool {
+		return strings.EqualFold(s.Country, country)
+	})
+}
+
+// SourcesByCategory returns the sources of the snapshot in the
+// category, for example "technology".
+func SourcesByCategory(category string) []Source {
+	return filterSources(func(s Source) bool {
+		return strings.EqualFold(s.Category, category)
+	})
+}
+
+func filterSources(match func(Source) bool) []Source {
+	var result []Source
+	for _, s := range sourcesSnapshot {
+		if match(s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// maxSources is the maximum number of sources in one request.
+const maxSources = 20
+
+// Validate checks the options without sending a request. The
+// codes of the exceptions are the same the api would return.
+// Sources that are not in the snapshot are rejected unless
+// AllowUnknownSources is set, because the snapshot can be older
+// than the api.
+func (opt TopHeadlinesOptions) Validate() *Exception {
+	if len(opt.Sources) == 0 {
+		return nil
+	}
+	if opt.Country != "" || opt.Category != "" {
+		return &Exception{
+			Code:    "parameterInvalid",
+			Message: "sources can't be mixed with the country or category",
+		}
+	}
+	if err := validateSources(opt.Sources); err != nil {
+		return err
+	}
+	if opt.AllowUnknownSources {
+		return nil
+	}
+	if unknown := UnknownSources(opt.Sources); len(unknown) > 0 {
+		return &Exception{
+			Code:    "sourceDoesNotExist",
+			Message: "unknown sources: " + strings.Join(unknown, ", "),
+		}
+	}
+	return nil
+}
+
+// maxPageSize is the maximum number of results per page.
+const maxPageSize = 100
+
+// Validate checks the options without sending a request, see
+// TopHeadlinesOptions.Validate.
+func (opt EverythingOptions) Validate() *Exception {
+	if opt.Query == "" && len(opt.Sources) == 0 && len(opt.Domains) == 0 {
+		return &Exception{
+			Code:    "parametersMissing",
+			Message: "the query, sources or domains are required",
+		}
+	}
+	if opt.PageSize > maxPageSize {
+		return &Exception{
+			Code:    "parameterInvalid",
+			Message: fmt.Sprintf("the page size %d is larger than the maximum of %d", opt.PageSize, maxPageSize),
+		}
+	}
+	return validateSources(opt.Sources)
+}
+
+// categories are the categories the api knows.
+var categories = []string{"business", "entertainment", "general", "health", "science", "sports", "technology"}
+
+// Validate checks the options without sending a request, see
+// TopHeadlinesOptions.Validate.
+func (opt SourcesOptions) Validate() *Exception {
+	if opt.Category == "" {
+		return nil
+	}
+	for _, c := range categories {
+		if strings.EqualFold(opt.Category, c) {
+			return nil
+		}
+	}
+	return &Exception{
+		Code:    "parameterInvalid",
+		Message: fmt.Sprintf("unknown category %q, expected one of %s", opt.Category, strings.Join(categories, ", ")),
+	}
+}
+
+func validateSources(ids []string) *Exception {
+	if len(ids) > maxSources {
+		return &Exception{
+			Code:    "sourcesTooMany",
+			Message: fmt.Sprintf("%d sources were requested, the maximum is %d", len(ids), maxSources),
+		}
+	}
+
+	for _, id := range ids {
+		// a comma would split the id in the query
+		if strings.TrimSpace(id) == "" || strings.ContainsAny(id, ", ") {
+			return &Exception{
+				Code:    "parameterInvalid",
+				Message: fmt.Sprintf("%q is not a source id", id),
+			}
+		}
+	}
+	return nil
+}
+
+// UnknownSources returns the ids that are not in the snapshot. They
+// can be typos or sources that were added after the snapshot.
+func UnknownSources(ids []string) []string {
+	var unknown []string
+	for _, id := range ids {
+		if _, ok := SourceByID(id); !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	return unknown
+}
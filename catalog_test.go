@@ -0,0 +1,122 @@
+package news
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+)
+
+func TestSourceByID(t *testing.T) {
+	t.Parallel()
+
+	s, ok := SourceByID("spiegel-online")
+	if !ok || s.Country != "de" || s.Language != "de" {
+		t.Fatalf("expected spiegel-online from germany but got %+v", s)
+	}
+	if _, ok := SourceByID("does-not-exist"); ok {
+		t.Fatal("did not expect an unknown source")
+	}
+}
+
+func TestSourcesByCountryAndCategory(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range SourcesByCountry("DE") {
+		if s.Country != "de" {
+			t.Fatalf("expected only german sources but got %+v", s)
+		}
+	}
+	if len(SourcesByCountry("de")) == 0 {
+		t.Fatal("expected german sources")
+	}
+
+	tech := SourcesByCategory("technology")
+	if len(tech) == 0 {
+		t.Fatal("expected technology sources")
+	}
+	for _, s := range tech {
+		if s.Category != "technology" {
+			t.Fatalf("expected only technology sources but got %+v", s)
+		}
+	}
+
+	all := KnownSources()
+	if !sort.SliceIsSorted(all, func(i, j int) bool { return all[i].ID < all[j].ID }) {
+		t.Fatal("expected the snapshot to be sorted by id")
+	}
+}
+
+func TestTopHeadlinesOptions_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		opt  TopHeadlinesOptions
+		code string
+	}{
+		{TopHeadlinesOptions{Country: "de"}, ""},
+		{TopHeadlinesOptions{Sources: []string{"bbc-news", "cnn"}}, ""},
+		{TopHeadlinesOptions{Sources: []string{"bbc-news", "not-in-the-snapshot"}}, "sourceDoesNotExist"},
+		{TopHeadlinesOptions{Sources: []string{"not-in-the-snapshot"}, AllowUnknownSources: true}, ""},
+		{TopHeadlinesOptions{Sources: []string{"bbc-news,cnn"}}, "parameterInvalid"},
+		{TopHeadlinesOptions{Sources: []string{"bbc-news"}, Country: "gb"}, "parameterInvalid"},
+		{TopHeadlinesOptions{Sources: make([]string, 21)}, "sourcesTooMany"},
+	}
+	for _, test := range tests {
+		err := test.opt.Validate()
+		if (err == nil && test.code != "") || (err != nil && err.Code != test.code) {
+			t.Errorf("expected %q for %+v but got %v", test.code, test.opt, err)
+		}
+	}
+}
+
+func TestEverythingOptions_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		opt  EverythingOptions
+		code string
+	}{
+		{EverythingOptions{Query: "golang"}, ""},
+		{EverythingOptions{Domains: []string{"golang.org"}}, ""},
+		{EverythingOptions{}, "parametersMissing"},
+		{EverythingOptions{Query: "golang", PageSize: 101}, "parameterInvalid"},
+		{EverythingOptions{Sources: []string{"bbc news"}}, "parameterInvalid"},
+		{EverythingOptions{Sources: make([]string, 21)}, "sourcesTooMany"},
+	}
+	for _, test := range tests {
+		err := test.opt.Validate()
+		if (err == nil && test.code != "") || (err != nil && err.Code != test.code) {
+			t.Errorf("expected %q for %+v but got %v", test.code, test.opt, err)
+		}
+	}
+
+	if err := (SourcesOptions{Category: "Technology"}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (SourcesOptions{Category: "cooking"}).Validate(); err == nil {
+		t.Fatal("expected the unknown category to be rejected")
+	}
+}
+
+func TestTopHeadlines_ValidateOptions(t *testing.T) {
+	t.Parallel()
+
+	c := mockClient(Config{ValidateOptions: true}, func(req *http.Request) (*http.Response, error) {
+		t.Fatal("did not expect a request")
+		return nil, nil
+	})
+
+	_, _, err := c.TopHeadlines(TopHeadlinesOptions{Sources: []string{"bbc-news", "cnn"}, Country: "us"})
+	if err == nil || err.Code != "parameterInvalid" {
+		t.Fatal("expected the mixed parameters to be rejected but got ", err)
+	}
+}
+
+func TestUnknownSources(t *testing.T) {
+	t.Parallel()
+
+	unknown := UnknownSources([]string{"bbc-news", "not-a-source", " CNN "})
+	if len(unknown) != 1 || unknown[0] != "not-a-source" {
+		t.Fatal("expected only the missing source but got ", unknown)
+	}
+}
@@ -0,0 +1,177 @@
+package news
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the url of the news api.
+const DefaultBaseURL = "https://newsapi.org/v2"
+
+// Config contains the settings of a Client. The fields have the
+// same meaning as the package level variables.
+type Config struct {
+	APIKey string
+
+	// BaseURL is the url the endpoints are appended to.
+	// Default: DefaultBaseURL
+	BaseURL string
+
+	Headers map[string]string
+
+	// HTTPClient is used for every request. If it is nil a
+	// http.Client with the Timeout and a shared transport
+	// is used.
+	HTTPClient httpClient
+
+	// Timeout is only used if HTTPClient is nil. Default: 10 seconds
+	Timeout time.Duration
+
+	// MaxBodySize is the maximum size of a response body in bytes.
+	// Default: DefaultMaxBodySize
+	MaxBodySize int64
+
+	// Keys is used for every request without an APIKey.
+	Keys *KeyPool
+
+	// Metrics collects the metrics of the requests.
+	Metrics *Metrics
+
+	// Tracer starts a span for every request.
+	Tracer Tracer
+
+	// ValidateOptions checks the options before every request.
+	ValidateOptions bool
+
+	Limiter      Limiter
+	Retry        RetryPolicy
+	ArticlesHook func(endpoint string, query url.Values, articles []Article)
+}
+
+// Client sends the requests to the news api. The settings can't be
+// changed after NewClient, so a Client is safe for concurrent use.
+type Client struct {
+	apiKey      string
+	baseURL     string
+	headers     map[string]string
+	httpClient  httpClient
+	maxBodySize int64
+	validate    bool
+	keys        *KeyPool
+	metrics     *Metrics
+	tracer      Tracer
+
+	// ctx is set by WithContext.
+	ctx          context.Context
+	limiter      Limiter
+	retry        RetryPolicy
+	articlesHook func(endpoint string, query url.Values, articles []Article)
+
+	// sleep waits for the backoff or until the context is
+	// canceled. It can be replaced in tests.
+	sleep func(context.Context, time.Duration) error
+}
+
+// NewClient creates a client with the settings of the config.
+func NewClient(cfg Config) *Client {
+	c := &Client{
+		apiKey:       cfg.APIKey,
+		baseURL:      cfg.BaseURL,
+		httpClient:   cfg.HTTPClient,
+		maxBodySize:  cfg.MaxBodySize,
+		validate:     cfg.ValidateOptions,
+		keys:         cfg.Keys,
+		metrics:      cfg.Metrics,
+		tracer:       cfg.Tracer,
+		limiter:      cfg.Limiter,
+		retry:        cfg.Retry,
+		articlesHook: cfg.ArticlesHook,
+		sleep:        sleepContext,
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+	if c.maxBodySize <= 0 {
+		c.maxBodySize = DefaultMaxBodySize
+	}
+	if c.httpClient == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		c.httpClient = &http.Client{
+			Timeout:   timeout,
+			Transport: defaultTransport,
+		}
+	}
+
+	// the map of the caller could still be changed later
+	c.headers = make(map[string]string, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		c.headers[k] = v
+	}
+
+	return c
+}
+
+// sleepContext waits for d or until the context is canceled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var (
+	defaultMu sync.RWMutex
+	defaultC  *Client
+)
+
+// SetDefault sets the client of the package level functions like
+// TopHeadlines. With nil the next call creates a new client from
+// the package level variables.
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defaultC = c
+	defaultMu.Unlock()
+}
+
+// defaultClient returns the client of the package level functions.
+// It is created from the package level variables on first use,
+// later changes of the variables are ignored until SetDefault.
+func defaultClient() *Client {
+	defaultMu.RLock()
+	c := defaultC
+	defaultMu.RUnlock()
+	if c != nil {
+		return c
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultC == nil {
+		defaultC = NewClient(Config{
+			APIKey:          APIKey,
+			Headers:         Headers,
+			HTTPClient:      HTTPClient,
+			Timeout:         Timeout,
+			MaxBodySize:     MaxBodySize,
+			ValidateOptions: ValidateOptions,
+			Keys:            APIKeys,
+			Metrics:         RequestMetrics,
+			Tracer:          RequestTracer,
+			Limiter:         RequestLimiter,
+			Retry:           Retry,
+			ArticlesHook:    ArticlesHook,
+		})
+	}
+	return defaultC
+}
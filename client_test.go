@@ -0,0 +1,116 @@
+package news
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestPackageLevel changes the package level variables, so it can't
+// run in parallel. Parallel tests only start after it finished.
+func TestPackageLevel(t *testing.T) {
+	APIKey = "abc"
+	Headers = map[string]string{"key": "value"}
+	defer func() {
+		APIKey = ""
+		Headers = nil
+		HTTPClient = nil
+		SetDefault(nil)
+	}()
+
+	HTTPClient = &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if key := req.URL.Query().Get("apiKey"); key != "abc" {
+				t.Fatal("expected the global api key but got ", key)
+			}
+			if req.Header.Get("key") != "value" {
+				t.Fatal("expected the global headers")
+			}
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		},
+	}
+
+	SetDefault(nil)
+
+	if _, _, err := TopHeadlines(TopHeadlinesOptions{}); err == nil {
+		t.Fatal("expected an error because of the missing body")
+	}
+	if defaultClient() != defaultClient() {
+		t.Fatal("expected the package level functions to share one client")
+	}
+
+	// the variables are frozen after the first call
+	APIKey = "changed"
+	if _, _, err := Everything(EverythingOptions{}); err == nil {
+		t.Fatal("expected an error because of the missing body")
+	}
+	if _, _, err := Sources(SourcesOptions{}); err == nil {
+		t.Fatal("expected an error because of the missing body")
+	}
+}
+
+func TestPackageLevel_Timeout(t *testing.T) {
+	Timeout = 3 * time.Second
+	SetDefault(nil)
+	defer func() {
+		Timeout = 10 * time.Second
+		SetDefault(nil)
+	}()
+
+	c, ok := defaultClient().httpClient.(*http.Client)
+	if !ok {
+		t.Fatal("expected a *http.Client")
+	}
+	if c.Timeout != 3*time.Second {
+		t.Fatal("expected the changed timeout but got ", c.Timeout)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	var requested bool
+	SetDefault(mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		requested = true
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+	defer SetDefault(nil)
+
+	Sources(SourcesOptions{})
+	if !requested {
+		t.Fatal("expected the client of SetDefault to be used")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	headers := map[string]string{"key": "value"}
+	c := NewClient(Config{Headers: headers, Timeout: time.Second})
+
+	// changing the map afterwards does not change the client
+	headers["key"] = "changed"
+	if c.headers["key"] != "value" {
+		t.Fatal("expected the client to copy the headers")
+	}
+
+	if c.baseURL != DefaultBaseURL {
+		t.Fatal("expected the default base url but got ", c.baseURL)
+	}
+	if c.httpClient.(*http.Client).Timeout != time.Second {
+		t.Fatal("expected the timeout to be used")
+	}
+}
+
+func TestNewClient_BaseURL(t *testing.T) {
+	t.Parallel()
+
+	var requested string
+	c := mockClient(Config{BaseURL: "http://localhost:8080/v2"}, func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	c.TopHeadlines(TopHeadlinesOptions{})
+	if requested != "http://localhost:8080/v2/top-headlines" {
+		t.Fatal("expected the base url to be used but got ", requested)
+	}
+}
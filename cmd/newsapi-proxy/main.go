@@ -0,0 +1,75 @@
+// Command newsapi-proxy is a caching reverse proxy for the news api.
+// It holds the one real api key and hands out local tokens to the
+// apps of a team so that they never see the key.
+//
+//	NEWS_API_KEY=... newsapi-proxy -tokens tokens.json -daily-limit 1000
+//
+// The tokens file maps every token to a name:
+//
+//	{"3f2a...": "frontend", "9c1b...": "ticker"}
+//
+// Callers pass their token like a normal api key (`apiKey` query
+// parameter, `X-Api-Key` header or `Authorization: Bearer`) to the
+// same /v2/top-headlines, /v2/everything and /v2/sources endpoints.
+// GET /usage returns the usage of the token (or of all tokens
+// when called with the -admin-token).
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "the address to listen on")
+	upstream := flag.String("upstream", "https://newsapi.org", "the url of the news api")
+	tokensFile := flag.String("tokens", "tokens.json", "json file that maps tokens to names")
+	adminToken := flag.String("admin-token", "", "token that can see the usage of every token")
+	ttl := flag.Duration("cache", 5*time.Minute, "how long responses are cached (0 to disable)")
+	dailyLimit := flag.Int("daily-limit", 0, "maximum upstream requests per day (0 for no limit)")
+	flag.Parse()
+
+	apiKey := os.Getenv("NEWS_API_KEY")
+	if apiKey == "" {
+		log.Fatal("error: the environment variable NEWS_API_KEY needs to be set.")
+	}
+
+	tokens, err := loadTokens(*tokensFile)
+	if err != nil {
+		log.Fatal("error: loading the tokens: ", err)
+	}
+
+	cfg := news.Config{
+		BaseURL: strings.TrimSuffix(*upstream, "/") + "/v2",
+		APIKey:  apiKey,
+	}
+	if *dailyLimit > 0 {
+		cfg.Limiter = news.NewQuotaLimiter(*dailyLimit, 24*time.Hour)
+	}
+
+	p := newProxy(cfg, tokens)
+	p.adminToken = *adminToken
+	p.ttl = *ttl
+
+	log.Println("[newsapi-proxy] listening on", *addr)
+	log.Fatal(http.ListenAndServe(*addr, p))
+}
+
+func loadTokens(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tokens := make(map[string]string)
+	err = json.NewDecoder(f).Decode(&tokens)
+	return tokens, err
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// defaultMaxEntries is the number of responses the cache keeps.
+const defaultMaxEntries = 1000
+
+// response is an upstream response that can be cached
+// and shared between callers.
+type response struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// call is an upstream request that is currently in flight.
+// Callers asking for the same url wait for it instead of
+// sending their own request.
+type call struct {
+	done chan struct{}
+	res  *response
+	err  *news.Exception
+}
+
+// source is where a response came from.
+type source int
+
+const (
+	fromUpstream source = iota
+	fromCache
+	fromInflight
+)
+
+// usage counts the requests of one token.
+type usage struct {
+	Name      string `json:"name"`
+	Requests  int    `json:"requests"`
+	CacheHits int    `json:"cacheHits"`
+	Coalesced int    `json:"coalesced"`
+	Upstream  int    `json:"upstream"`
+	Errors    int    `json:"errors"`
+}
+
+// proxy sends the requests with a news.Client, so the real key, the
+// limiter, the retries and the body limit of the library are used.
+// The client has no response cache, so the proxy caches and coalesces
+// the responses itself.
+type proxy struct {
+	adminToken string
+	ttl        time.Duration
+	maxEntries int
+
+	client  *news.Client
+	limiter news.Limiter
+
+	// tokens maps the local tokens to a name.
+	tokens map[string]string
+
+	mu       sync.Mutex
+	cache    map[string]*response
+	inflight map[string]*call
+	usage    map[string]*usage
+}
+
+// newProxy forwards to the api with the config. The BaseURL is the
+// url of the api including /v2.
+func newProxy(cfg news.Config, tokens map[string]string) *proxy {
+	return &proxy{
+		ttl:        5 * time.Minute,
+		maxEntries: defaultMaxEntries,
+		client:     news.NewClient(cfg),
+		limiter:    cfg.Limiter,
+		tokens:     tokens,
+		cache:      make(map[string]*response),
+		inflight:   make(map[string]*call),
+		usage:      make(map[string]*usage),
+	}
+}
+
+// writeError writes an error in the same format as the news api.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "error",
+		"code":    code,
+		"message": message,
+	})
+}
+
+// token returns the token of the caller. It can be passed the same
+// ways as the api key of the news api.
+func token(r *http.Request) string {
+	if t := r.URL.Query().Get("apiKey"); t != "" {
+		return t
+	}
+	if t := r.Header.Get("X-Api-Key"); t != "" {
+		return t
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := token(r)
+	if r.URL.Path == "/usage" && p.adminToken != "" && subtle.ConstantTimeCompare([]byte(t), []byte(p.adminToken)) == 1 {
+		p.serveUsage(w, "")
+		return
+	}
+
+	name, ok := p.tokens[t]
+	if t == "" || !ok {
+		writeError(w, http.StatusUnauthorized, "apiKeyInvalid", "Your token is invalid or incorrect.")
+		return
+	}
+	if r.URL.Path == "/usage" {
+		p.serveUsage(w, t)
+		return
+	}
+	if !endpoints[r.URL.Path] {
+		writeError(w, http.StatusNotFound, "notFound", "The endpoint "+r.URL.Path+" does not exist.")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "methodNotAllowed", "Only GET requests are supported.")
+		return
+	}
+
+	// the token must not be forwarded and is not
+	// part of the cache key.
+	query := r.URL.Query()
+	query.Del("apiKey")
+	if err := checkQuery(r.URL.Path, query); err != nil {
+		writeError(w, http.StatusBadRequest, "parameterInvalid", err.Error())
+		return
+	}
+	key := r.URL.Path + "?" + query.Encode()
+	fresh := r.Header.Get("X-No-Cache") == "true"
+
+	res, from, err := p.get(key, fresh)
+
+	p.mu.Lock()
+	u := p.usage[t]
+	if u == nil {
+		u = &usage{Name: name}
+		p.usage[t] = u
+	}
+	u.Requests++
+	switch {
+	case err != nil:
+		u.Errors++
+	case from == fromCache:
+		u.CacheHits++
+	case from == fromInflight:
+		u.Coalesced++
+	default:
+		u.Upstream++
+	}
+	p.mu.Unlock()
+
+	if err != nil && err.Code == "[limiter]" {
+		writeError(w, http.StatusTooManyRequests, "rateLimited", "The daily limit of the proxy is reached.")
+		return
+	}
+	if err != nil {
+		log.Println("[newsapi-proxy] error:", err)
+		writeError(w, http.StatusBadGateway, "unexpectedError", "The news api could not be reached.")
+		return
+	}
+
+	for k, v := range res.header {
+		w.Header()[k] = v
+	}
+	if from == fromUpstream {
+		w.Header().Set("X-Proxy-Cache", "MISS")
+	} else {
+		w.Header().Set("X-Proxy-Cache", "HIT")
+	}
+	w.WriteHeader(res.status)
+	w.Write(res.body)
+}
+
+// get returns the response for the key either from the cache, from
+// a request that is already in flight or from a new upstream request.
+// A fresh request skips both, so that it never gets the result of
+// another caller.
+func (p *proxy) get(key string, fresh bool) (*response, source, *news.Exception) {
+	p.mu.Lock()
+	if res := p.cache[key]; res != nil && !fresh && time.Now().Before(res.expires) {
+		p.mu.Unlock()
+		return res, fromCache, nil
+	}
+	if c := p.inflight[key]; c != nil && !fresh {
+		p.mu.Unlock()
+		<-c.done
+		return c.res, fromInflight, c.err
+	}
+
+	c := &call{done: make(chan struct{})}
+	if !fresh {
+		p.inflight[key] = c
+	}
+	p.mu.Unlock()
+
+	c.res, c.err = p.fetch(key, fresh)
+
+	p.mu.Lock()
+	if !fresh {
+		delete(p.inflight, key)
+	}
+	if c.err == nil && c.res.status == http.StatusOK && p.ttl > 0 {
+		c.res.expires = time.Now().Add(p.ttl)
+		p.store(key, c.res)
+	}
+	p.mu.Unlock()
+	close(c.done)
+
+	return c.res, fromUpstream, c.err
+}
+
+// store adds the response to the cache. If the cache is full the
+// expired responses are removed, and if that isn't enough the one
+// that expires first. The caller holds p.mu.
+func (p *proxy) store(key string, res *response) {
+	if _, ok := p.cache[key]; !ok && len(p.cache) >= p.maxEntries {
+		now := time.Now()
+		for k, r := range p.cache {
+			if !now.Before(r.expires) {
+				delete(p.cache, k)
+			}
+		}
+
+		for len(p.cache) >= p.maxEntries {
+			var oldest string
+			var expires time.Time
+			for k, r := range p.cache {
+				if oldest == "" || r.expires.Before(expires) {
+					oldest, expires = k, r.expires
+				}
+			}
+			delete(p.cache, oldest)
+		}
+	}
+	p.cache[key] = res
+}
+
+// fetch sends the request with the client. An error of the api is a
+// response with its status code, the returned exception is only set
+// if the api couldn't be asked (the codes in brackets).
+func (p *proxy) fetch(key string, fresh bool) (*response, *news.Exception) {
+	u, err := url.Parse(key)
+	if err != nil {
+		return nil, &news.Exception{Code: "[parsing the url]", Message: err.Error()}
+	}
+	q := u.Query()
+
+	var body interface{}
+	var info *news.ResponseInfo
+	var exc *news.Exception
+	switch u.Path {
+	case "/v2/top-headlines":
+		var articles []news.Article
+		articles, info, exc = p.client.TopHeadlines(topHeadlinesOptions(q, fresh))
+		body = articlesBody(articles, info)
+	case "/v2/everything":
+		var articles []news.Article
+		articles, info, exc = p.client.Everything(everythingOptions(q, fresh))
+		body = articlesBody(articles, info)
+	default:
+		var sources []news.Source
+		sources, info, exc = p.client.Sources(news.SourcesOptions{
+			ForceFreshData: fresh,
+			Category:       q.Get("category"),
+			Language:       q.Get("language"),
+			Country:        q.Get("country"),
+		})
+		if sources == nil {
+			sources = []news.Source{}
+		}
+		body = map[string]interface{}{"status": "ok", "sources": sources}
+	}
+
+	if exc != nil {
+		if strings.HasPrefix(exc.Code, "[") {
+			return nil, exc
+		}
+		body = map[string]string{"status": "error", "code": exc.Code, "message": exc.Message}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, &news.Exception{Code: "[encoding json]", Message: err.Error()}
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	if exc != nil {
+		return &response{status: errorStatus(exc.Code), header: header, body: data}, nil
+	}
+	if info.Date != "" {
+		header.Set("Date", info.Date)
+	}
+	header.Set("X-Cached-Result", strconv.FormatBool(info.Cached))
+	if info.Expires != "" {
+		header.Set("X-Cache-Expires", info.Expires)
+	}
+	if info.Remaining != "" {
+		header.Set("X-Cache-Remaining", info.Remaining)
+	}
+	return &response{status: http.StatusOK, header: header, body: data}, nil
+}
+
+func articlesBody(articles []news.Article, info *news.ResponseInfo) interface{} {
+	total := 0
+	if info != nil {
+		total = info.TotalResults
+	}
+	if articles == nil {
+		articles = []news.Article{}
+	}
+	return map[string]interface{}{"status": "ok", "totalResults": total, "articles": articles}
+}
+
+// errorStatus returns the status code the api uses for the code.
+func errorStatus(code string) int {
+	switch code {
+	case "apiKeyDisabled", "apiKeyExhausted", "apiKeyInvalid", "apiKeyMissing":
+		return http.StatusUnauthorized
+	case "rateLimited":
+		return http.StatusTooManyRequests
+	case "unexpectedError":
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// endpoints are the endpoints that get forwarded to the news api
+// with the parameters the options of the client support.
+var endpoints = map[string]bool{
+	"/v2/top-headlines": true,
+	"/v2/everything":    true,
+	"/v2/sources":       true,
+}
+
+var parameters = map[string][]string{
+	"/v2/top-headlines": {"country", "category", "sources", "q", "language", "page", "pageSize"},
+	"/v2/everything":    {"q", "sources", "domains", "from", "to", "language", "sortBy", "page", "pageSize"},
+	"/v2/sources":       {"category", "language", "country"},
+}
+
+// checkQuery rejects parameters that the client can't forward,
+// instead of silently dropping them.
+func checkQuery(path string, q url.Values) error {
+	for name, values := range q {
+		known := false
+		for _, p := range parameters[path] {
+			known = known || p == name
+		}
+		if !known {
+			return fmt.Errorf("The parameter %s is not supported by the proxy.", name)
+		}
+		if name == "page" || name == "pageSize" {
+			if _, err := strconv.Atoi(values[0]); err != nil {
+				return fmt.Errorf("The parameter %s is not a number.", name)
+			}
+		}
+	}
+	return nil
+}
+
+func topHeadlinesOptions(q url.Values, fresh bool) news.TopHeadlinesOptions {
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+	return news.TopHeadlinesOptions{
+		ForceFreshData: fresh,
+		Sources:        list(q.Get("sources")),
+		Query:          q.Get("q"),
+		Category:       q.Get("category"),
+		Language:       q.Get("language"),
+		Country:        q.Get("country"),
+		Page:           page,
+		PageSize:       pageSize,
+
+		// the api knows better than the snapshot
+		AllowUnknownSources: true,
+	}
+}
+
+func everythingOptions(q url.Values, fresh bool) news.EverythingOptions {
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+	return news.EverythingOptions{
+		ForceFreshData: fresh,
+		Query:          q.Get("q"),
+		Sources:        list(q.Get("sources")),
+		Domains:        list(q.Get("domains")),
+		From:           q.Get("from"),
+		To:             q.Get("to"),
+		Language:       q.Get("language"),
+		SortBy:         q.Get("sortBy"),
+		Page:           page,
+		PageSize:       pageSize,
+	}
+}
+
+// list splits a comma separated parameter.
+func list(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func (p *proxy) serveUsage(w http.ResponseWriter, t string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// two tokens can have the same name, so the usage is
+	// keyed by the token
+	result := make(map[string]usage)
+	for token, u := range p.usage {
+		if t == "" || t == token {
+			result[token] = *u
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	remaining := -1
+	if l, ok := p.limiter.(interface{ Remaining() int }); ok {
+		remaining = l.Remaining()
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"usage":     result,
+		"remaining": remaining,
+	})
+}
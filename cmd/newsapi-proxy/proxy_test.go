@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+func newTestProxy(t *testing.T, cfg news.Config, handler http.HandlerFunc) (*proxy, *int32) {
+	var requests int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Query().Get("apiKey") != "real-key" {
+			t.Error("expected the real api key instead of the token but got ", r.URL.Query().Get("apiKey"))
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(upstream.Close)
+
+	cfg.BaseURL = upstream.URL + "/v2"
+	cfg.APIKey = "real-key"
+	p := newProxy(cfg, map[string]string{
+		"token-a": "frontend",
+		"token-b": "ticker",
+	})
+	return p, &requests
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok","totalResults":0,"articles":[]}`))
+}
+
+func get(p *proxy, url string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+	return rec
+}
+
+func TestProxy_InvalidToken(t *testing.T) {
+	p, requests := newTestProxy(t, news.Config{}, ok)
+
+	rec := get(p, "/v2/top-headlines?country=de&apiKey=wrong")
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatal("expected status 401 but got ", rec.Code)
+	}
+	if atomic.LoadInt32(requests) != 0 {
+		t.Fatal("expected no upstream request")
+	}
+}
+
+func TestProxy_Cache(t *testing.T) {
+	p, requests := newTestProxy(t, news.Config{}, ok)
+
+	first := get(p, "/v2/top-headlines?country=de&apiKey=token-a")
+	if first.Code != http.StatusOK || first.Header().Get("X-Proxy-Cache") != "MISS" {
+		t.Fatal("expected a cache miss but got ", first.Code, first.Header())
+	}
+
+	// a different token shares the cache
+	second := get(p, "/v2/top-headlines?country=de&apiKey=token-b")
+	if second.Header().Get("X-Proxy-Cache") != "HIT" {
+		t.Fatal("expected a cache hit")
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Fatal("expected the same body")
+	}
+	if atomic.LoadInt32(requests) != 1 {
+		t.Fatal("expected 1 upstream request but got ", atomic.LoadInt32(requests))
+	}
+
+	p.ttl = 0
+	p.cache = make(map[string]*response)
+	get(p, "/v2/top-headlines?country=de&apiKey=token-a")
+	get(p, "/v2/top-headlines?country=de&apiKey=token-a")
+	if atomic.LoadInt32(requests) != 3 {
+		t.Fatal("expected the cache to be disabled")
+	}
+}
+
+func TestProxy_Coalescing(t *testing.T) {
+	release := make(chan struct{})
+	p, requests := newTestProxy(t, news.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		ok(w, r)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get(p, "/v2/everything?q=bitcoin&apiKey=token-a")
+		}()
+	}
+
+	// wait for the first request to be in flight
+	for {
+		p.mu.Lock()
+		n := len(p.inflight)
+		p.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt32(requests) != 1 {
+		t.Fatal("expected 1 upstream request but got ", atomic.LoadInt32(requests))
+	}
+
+	// the waiters are not cache hits
+	u := p.usage["token-a"]
+	if u.Upstream != 1 || u.Coalesced != 4 || u.CacheHits != 0 {
+		t.Fatalf("unexpected usage: %+v", *u)
+	}
+}
+
+func TestProxy_FreshNotCoalesced(t *testing.T) {
+	release := make(chan struct{})
+	p, requests := newTestProxy(t, news.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-No-Cache") == "true" {
+			w.Write([]byte(`{"status":"ok","totalResults":1,"articles":[]}`))
+			return
+		}
+		<-release
+		ok(w, r)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		get(p, "/v2/everything?q=bitcoin&apiKey=token-a")
+	}()
+	for {
+		p.mu.Lock()
+		n := len(p.inflight)
+		p.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	req := httptest.NewRequest("GET", "/v2/everything?q=bitcoin&apiKey=token-b", nil)
+	req.Header.Set("X-No-Cache", "true")
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+
+	var res struct {
+		TotalResults int `json:"totalResults"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &res)
+	if res.TotalResults != 1 || rec.Header().Get("X-Proxy-Cache") != "MISS" {
+		t.Fatal("expected the fresh request to get its own response but got ", rec.Body.String())
+	}
+	if atomic.LoadInt32(requests) != 2 {
+		t.Fatal("expected 2 upstream requests but got ", atomic.LoadInt32(requests))
+	}
+
+	close(release)
+	<-done
+}
+
+func TestProxy_UnsupportedParameter(t *testing.T) {
+	p, requests := newTestProxy(t, news.Config{}, ok)
+
+	rec := get(p, "/v2/top-headlines?country=de&foo=bar&apiKey=token-a")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatal("expected status 400 but got ", rec.Code)
+	}
+	if atomic.LoadInt32(requests) != 0 {
+		t.Fatal("expected no upstream request")
+	}
+}
+
+func TestProxy_UpstreamError(t *testing.T) {
+	p, _ := newTestProxy(t, news.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"status":"error","code":"apiKeyInvalid","message":"invalid"}`))
+	})
+
+	rec := get(p, "/v2/sources?apiKey=token-a")
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "apiKeyInvalid") {
+		t.Fatal("expected the error of the api but got ", rec.Code, rec.Body.String())
+	}
+	if len(p.cache) != 0 {
+		t.Fatal("errors should not be cached")
+	}
+}
+
+func TestProxy_DailyLimit(t *testing.T) {
+	p, _ := newTestProxy(t, news.Config{Limiter: news.NewQuotaLimiter(1, 24*time.Hour)}, ok)
+
+	get(p, "/v2/sources?apiKey=token-a")
+	rec := get(p, "/v2/sources?country=de&apiKey=token-a")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatal("expected status 429 but got ", rec.Code)
+	}
+
+	// the cached response is still available
+	rec = get(p, "/v2/sources?apiKey=token-a")
+	if rec.Code != http.StatusOK {
+		t.Fatal("expected status 200 but got ", rec.Code)
+	}
+}
+
+func TestProxy_Usage(t *testing.T) {
+	p, _ := newTestProxy(t, news.Config{}, ok)
+	p.adminToken = "admin"
+	p.tokens["token-c"] = "frontend"
+
+	get(p, "/v2/sources?apiKey=token-a")
+	get(p, "/v2/sources?apiKey=token-a")
+	get(p, "/v2/sources?apiKey=token-b")
+	get(p, "/v2/sources?apiKey=token-c")
+
+	var res struct {
+		Usage map[string]usage `json:"usage"`
+	}
+	rec := get(p, "/usage?apiKey=token-a")
+	json.Unmarshal(rec.Body.Bytes(), &res)
+	if len(res.Usage) != 1 {
+		t.Fatal("a token should only see its own usage but got ", res.Usage)
+	}
+	u := res.Usage["token-a"]
+	if u.Requests != 2 || u.Upstream != 1 || u.CacheHits != 1 {
+		t.Fatalf("unexpected usage: %+v", u)
+	}
+
+	rec = get(p, "/usage?apiKey=admin")
+	json.Unmarshal(rec.Body.Bytes(), &res)
+	if len(res.Usage) != 3 || res.Usage["token-c"].Requests != 1 {
+		t.Fatal("the admin should see every token but got ", res.Usage)
+	}
+}
+
+func TestProxy_CacheSize(t *testing.T) {
+	p, _ := newTestProxy(t, news.Config{}, ok)
+	p.maxEntries = 2
+
+	for _, q := range []string{"a", "b", "c"} {
+		get(p, "/v2/everything?q="+q+"&apiKey=token-a")
+	}
+	if len(p.cache) != 2 {
+		t.Fatal("expected 2 cached responses but got ", len(p.cache))
+	}
+	if _, ok := p.cache["/v2/everything?q=a"]; ok {
+		t.Fatal("expected the oldest response to be removed")
+	}
+
+	// the expired responses are removed first
+	for _, res := range p.cache {
+		res.expires = time.Now().Add(-time.Second)
+	}
+	get(p, "/v2/everything?q=d&apiKey=token-a")
+	if len(p.cache) != 1 {
+		t.Fatal("expected the expired responses to be removed but got ", len(p.cache))
+	}
+}
+
+func TestProxy_BodyLimit(t *testing.T) {
+	p, _ := newTestProxy(t, news.Config{MaxBodySize: 10}, ok)
+
+	rec := get(p, "/v2/sources?apiKey=token-a")
+	if rec.Code != http.StatusBadGateway {
+		t.Fatal("expected status 502 for a large body but got ", rec.Code)
+	}
+}
@@ -0,0 +1,278 @@
+// Command newsapi queries the news api from the command line.
+//
+//	newsapi top-headlines -country de
+//	newsapi everything -q bitcoin -feed rss -o bitcoin.xml
+//	newsapi sources -country de
+//	newsapi sources diff old.json new.json
+//
+// `sources diff` compares two files written by `newsapi sources`
+// (or a second file with the live endpoint if it is left out). It
+// exits with the status 3 if the sources changed, so it can be used
+// to alert from a cron job.
+//
+// The api key is read from the environment variable NEWS_API_KEY
+// or can be passed with the -key flag.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+	"github.com/JohannesKaufmann/News-API-go/feed"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: newsapi <top-headlines|everything|sources> [flags]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "top-headlines":
+		err = topHeadlines(os.Args[2:])
+	case "everything":
+		err = everything(os.Args[2:])
+	case "sources":
+		err = sources(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err == errChanged {
+		os.Exit(3)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// output contains the flags that every command that returns
+// articles shares.
+type output struct {
+	key    *string
+	format *string
+	file   *string
+	title  *string
+	link   *string
+}
+
+func outputFlags(fs *flag.FlagSet) output {
+	return output{
+		key:    fs.String("key", os.Getenv("NEWS_API_KEY"), "the api key (default: $NEWS_API_KEY)"),
+		format: fs.String("feed", "", "write the articles as a feed: rss, atom or json"),
+		file:   fs.String("o", "", "the file to write to (default: stdout)"),
+		title:  fs.String("title", "News API", "the title of the feed"),
+		link:   fs.String("link", "https://newsapi.org", "the link of the feed"),
+	}
+}
+
+func (o output) writeArticles(articles []news.Article, description string) (err error) {
+	// check the format before the file is created, so that a typo
+	// doesn't leave an empty file behind
+	switch feed.Format(*o.format) {
+	case "", feed.RSS, feed.Atom, feed.JSON:
+	default:
+		return fmt.Errorf("unknown feed format %q", *o.format)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *o.file != "" {
+		f, err := os.Create(*o.file)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = f
+	}
+
+	if *o.format == "" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(articles)
+	}
+
+	ch := feed.Channel{
+		Title:       *o.title,
+		Link:        *o.link,
+		Description: description,
+	}
+	return feed.Write(w, feed.Format(*o.format), ch, articles)
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func topHeadlines(args []string) error {
+	fs := flag.NewFlagSet("top-headlines", flag.ExitOnError)
+	out := outputFlags(fs)
+
+	var opt news.TopHeadlinesOptions
+	sources := fs.String("sources", "", "comma-seperated list of source ids")
+	fs.StringVar(&opt.Query, "q", "", "keywords or phrase to search for")
+	fs.StringVar(&opt.Category, "category", "", "the category")
+	fs.StringVar(&opt.Language, "language", "", "the 2-letter ISO-639-1 code of the language")
+	fs.StringVar(&opt.Country, "country", "", "the 2-letter ISO 3166-1 code of the country")
+	fs.Parse(args)
+
+	opt.Sources = split(*sources)
+	opt.APIKey = *out.key
+
+	articles, _, err := news.TopHeadlines(opt)
+	if err != nil {
+		return err
+	}
+	return out.writeArticles(articles, "Top headlines from the News API")
+}
+
+func everything(args []string) error {
+	fs := flag.NewFlagSet("everything", flag.ExitOnError)
+	out := outputFlags(fs)
+
+	var opt news.EverythingOptions
+	sources := fs.String("sources", "", "comma-seperated list of source ids")
+	domains := fs.String("domains", "", "comma-seperated list of domains")
+	fs.StringVar(&opt.Query, "q", "", "keywords or phrase to search for")
+	fs.StringVar(&opt.From, "from", "", "the date of the oldest article")
+	fs.StringVar(&opt.To, "to", "", "the date of the newest article")
+	fs.StringVar(&opt.Language, "language", "", "the 2-letter ISO-639-1 code of the language")
+	fs.StringVar(&opt.SortBy, "sort-by", "", "relevancy, popularity or publishedAt")
+	fs.IntVar(&opt.Page, "page", 0, "the page of the results")
+	fs.Parse(args)
+
+	opt.Sources = split(*sources)
+	opt.Domains = split(*domains)
+	opt.APIKey = *out.key
+
+	articles, _, err := news.Everything(opt)
+	if err != nil {
+		return err
+	}
+	return out.writeArticles(articles, "Articles about "+opt.Query+" from the News API")
+}
+
+func sources(args []string) error {
+	if len(args) > 0 && args[0] == "diff" {
+		return sourcesDiff(args[1:])
+	}
+
+	fs := flag.NewFlagSet("sources", flag.ExitOnError)
+	key := fs.String("key", os.Getenv("NEWS_API_KEY"), "the api key (default: $NEWS_API_KEY)")
+
+	var opt news.SourcesOptions
+	fs.StringVar(&opt.Category, "category", "", "the category")
+	fs.StringVar(&opt.Language, "language", "", "the 2-letter ISO-639-1 code of the language")
+	fs.StringVar(&opt.Country, "country", "", "the 2-letter ISO 3166-1 code of the country")
+	fs.Parse(args)
+
+	opt.APIKey = *key
+
+	sources, _, err := news.Sources(opt)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sources)
+}
+
+// errChanged is returned by `sources diff` if there are changes.
+var errChanged = errors.New("the sources changed")
+
+func sourcesDiff(args []string) error {
+	fs := flag.NewFlagSet("sources diff", flag.ExitOnError)
+	key := fs.String("key", os.Getenv("NEWS_API_KEY"), "the api key (default: $NEWS_API_KEY)")
+	asJSON := fs.Bool("json", false, "write the differences as json")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: newsapi sources diff [flags] old.json [new.json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	old, err := readSources(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var current []news.Source
+	if fs.NArg() == 2 {
+		current, err = readSources(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+	} else {
+		var exc *news.Exception
+		current, _, exc = news.Sources(news.SourcesOptions{APIKey: *key})
+		if exc != nil {
+			return exc
+		}
+	}
+
+	diff := news.DiffSources(old, current)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			return err
+		}
+	} else if _, err := diff.WriteTo(os.Stdout); err != nil {
+		return err
+	}
+
+	if !diff.Empty() {
+		return errChanged
+	}
+	return nil
+}
+
+// readSources reads a json array of sources or a response
+// of the sources endpoint.
+func readSources(path string) ([]news.Source, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var sources []news.Source
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &sources)
+	} else {
+		var res struct {
+			Sources []news.Source `json:"sources"`
+		}
+		err = json.Unmarshal(data, &res)
+		sources = res.Sources
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return sources, nil
+}
@@ -0,0 +1,293 @@
+package news
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The environment variables read by LoadConfig and NewClientFromEnv.
+// They all start with EnvPrefix.
+const (
+	EnvPrefix = "NEWS_API_"
+
+	EnvConfig          = EnvPrefix + "CONFIG"           // path of the json config file
+	EnvKey             = EnvPrefix + "KEY"              // the api key
+	EnvKeyFile         = EnvPrefix + "KEY_FILE"         // file that contains the api key
+	EnvKeys            = EnvPrefix + "KEYS"             // comma separated keys for a KeyPool
+	EnvKeyStrategy     = EnvPrefix + "KEY_STRATEGY"     // round-robin, least-used or primary-fallback
+	EnvBaseURL         = EnvPrefix + "BASE_URL"         // for example http://localhost:8080/v2
+	EnvTimeout         = EnvPrefix + "TIMEOUT"          // for example 10s
+	EnvMaxBodySize     = EnvPrefix + "MAX_BODY_SIZE"    // in bytes
+	EnvHeaders         = EnvPrefix + "HEADERS"          // for example "User-Agent=bot,X-Team=news"
+	EnvRetries         = EnvPrefix + "RETRIES"          // the MaxRetries of the RetryPolicy
+	EnvRetryBackoff    = EnvPrefix + "RETRY_BACKOFF"    // for example 1s
+	EnvLimitRequests   = EnvPrefix + "LIMIT_REQUESTS"   // requests of the QuotaLimiter
+	EnvLimitWindow     = EnvPrefix + "LIMIT_WINDOW"     // window of the QuotaLimiter, for example 24h
+	EnvValidateOptions = EnvPrefix + "VALIDATE_OPTIONS" // true or false
+)
+
+// FileConfig is the format of the json config file. The durations
+// are strings like "10s" or "24h".
+//
+//	{
+//	  "apiKeyFile": "/run/secrets/news-api-key",
+//	  "timeout": "5s",
+//	  "headers": {"User-Agent": "our-bot"},
+//	  "retry": {"maxRetries": 2, "backoff": "1s"},
+//	  "limiter": {"requests": 1000, "window": "24h"}
+//	}
+type FileConfig struct {
+	APIKey      string   `json:"apiKey"`
+	APIKeyFile  string   `json:"apiKeyFile"`
+	APIKeys     []string `json:"apiKeys"`
+	KeyStrategy string   `json:"keyStrategy"`
+
+	BaseURL     string            `json:"baseURL"`
+	Timeout     string            `json:"timeout"`
+	MaxBodySize int64             `json:"maxBodySize"`
+	Headers     map[string]string `json:"headers"`
+
+	Retry struct {
+		MaxRetries int    `json:"maxRetries"`
+		Backoff    string `json:"backoff"`
+	} `json:"retry"`
+
+	Limiter struct {
+		Requests int    `json:"requests"`
+		Window   string `json:"window"`
+	} `json:"limiter"`
+
+	ValidateOptions bool `json:"validateOptions"`
+}
+
+// ConfigError contains every problem of a configuration.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid news api config: " + strings.Join(e.Problems, "; ")
+}
+
+// LoadConfig reads the json file at path (if path is not empty) and
+// then the environment variables. The environment variables win over
+// the file, so a deployment can change single settings. A key from
+// a file (APIKeyFile or NEWS_API_KEY_FILE) is handled like the key
+// itself, and a key from the environment wins over keys from the
+// config file.
+//
+// There are no cache settings: the client has no response cache and
+// the cache of the api is skipped per request with ForceFreshData
+// (or for every request with the header X-No-Cache=true).
+func LoadConfig(path string) (Config, error) {
+	return loadConfig(path, os.Getenv)
+}
+
+// NewClientFromEnv creates a client from the environment variables
+// and the config file in NEWS_API_CONFIG (if it is set).
+func NewClientFromEnv() (*Client, error) {
+	cfg, err := loadConfig(os.Getenv(EnvConfig), os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(cfg), nil
+}
+
+func loadConfig(path string, getenv func(string) string) (Config, error) {
+	var fc FileConfig
+	problems := &ConfigError{}
+
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return Config{}, &ConfigError{Problems: []string{err.Error()}}
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&fc); err != nil {
+			return Config{}, &ConfigError{Problems: []string{path + ": " + err.Error()}}
+		}
+	}
+
+	fc.applyEnv(getenv, problems)
+	cfg := fc.config(problems)
+
+	if len(problems.Problems) > 0 {
+		return Config{}, problems
+	}
+	return cfg, nil
+}
+
+// applyEnv overwrites the fields that are set in the environment.
+func (fc *FileConfig) applyEnv(getenv func(string) string, problems *ConfigError) {
+	str := func(name string, target *string) {
+		if v := strings.TrimSpace(getenv(name)); v != "" {
+			*target = v
+		}
+	}
+	num := func(name string, target *int) {
+		if v := strings.TrimSpace(getenv(name)); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				problems.add("%s: %q is not a number", name, v)
+				return
+			}
+			*target = n
+		}
+	}
+
+	// a key from the environment replaces all keys of the file,
+	// a variable with only spaces (or commas) is not a key
+	if strings.TrimSpace(getenv(EnvKey)) != "" || strings.TrimSpace(getenv(EnvKeyFile)) != "" || len(splitList(getenv(EnvKeys))) > 0 {
+		fc.APIKey, fc.APIKeyFile, fc.APIKeys = "", "", nil
+	}
+	str(EnvKey, &fc.APIKey)
+	str(EnvKeyFile, &fc.APIKeyFile)
+	if keys := splitList(getenv(EnvKeys)); len(keys) > 0 {
+		fc.APIKeys = keys
+	}
+	str(EnvKeyStrategy, &fc.KeyStrategy)
+
+	str(EnvBaseURL, &fc.BaseURL)
+	str(EnvTimeout, &fc.Timeout)
+	if v := strings.TrimSpace(getenv(EnvMaxBodySize)); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			problems.add("%s: %q is not a number", EnvMaxBodySize, v)
+		}
+		fc.MaxBodySize = n
+	}
+	if v := getenv(EnvHeaders); v != "" {
+		if fc.Headers == nil {
+			fc.Headers = make(map[string]string)
+		}
+		for _, pair := range splitList(v) {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+				problems.add("%s: %q is not a name=value pair", EnvHeaders, pair)
+				continue
+			}
+			fc.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	num(EnvRetries, &fc.Retry.MaxRetries)
+	str(EnvRetryBackoff, &fc.Retry.Backoff)
+	num(EnvLimitRequests, &fc.Limiter.Requests)
+	str(EnvLimitWindow, &fc.Limiter.Window)
+
+	if v := strings.TrimSpace(getenv(EnvValidateOptions)); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			problems.add("%s: %q is not true or false", EnvValidateOptions, v)
+		}
+		fc.ValidateOptions = b
+	}
+}
+
+// config validates the settings and turns them into a Config.
+func (fc *FileConfig) config(problems *ConfigError) Config {
+	cfg := Config{
+		APIKey:          fc.APIKey,
+		BaseURL:         fc.BaseURL,
+		MaxBodySize:     fc.MaxBodySize,
+		Headers:         fc.Headers,
+		ValidateOptions: fc.ValidateOptions,
+	}
+
+	if fc.APIKeyFile != "" {
+		if fc.APIKey != "" {
+			problems.add("the api key and the api key file are both set")
+		}
+		data, err := ioutil.ReadFile(fc.APIKeyFile)
+		if err != nil {
+			// the error contains the path but never the key
+			problems.add("reading the api key file: %v", err)
+		} else if cfg.APIKey = strings.TrimSpace(string(data)); cfg.APIKey == "" {
+			problems.add("the api key file %s is empty", fc.APIKeyFile)
+		}
+	}
+
+	if len(fc.APIKeys) > 0 {
+		if cfg.APIKey != "" {
+			problems.add("a single api key and a list of api keys are both set")
+		}
+		strategy, ok := map[string]KeyStrategy{
+			"":                 RoundRobin,
+			"round-robin":      RoundRobin,
+			"least-used":       LeastUsed,
+			"primary-fallback": PrimaryFallback,
+		}[fc.KeyStrategy]
+		if !ok {
+			problems.add("unknown key strategy %q", fc.KeyStrategy)
+		}
+		cfg.Keys = NewKeyPool(fc.APIKeys, strategy)
+	}
+	if cfg.APIKey == "" && cfg.Keys == nil && fc.APIKeyFile == "" {
+		problems.add("no api key: set %s, %s or %s", EnvKey, EnvKeyFile, EnvKeys)
+	}
+
+	if fc.BaseURL != "" && !strings.HasPrefix(fc.BaseURL, "http://") && !strings.HasPrefix(fc.BaseURL, "https://") {
+		problems.add("the base url %q has to start with http:// or https://", fc.BaseURL)
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	cfg.Timeout = problems.duration("timeout", fc.Timeout)
+	if fc.MaxBodySize < 0 {
+		problems.add("the max body size can't be negative")
+	}
+
+	if fc.Retry.MaxRetries < 0 {
+		problems.add("the number of retries can't be negative")
+	}
+	cfg.Retry = RetryPolicy{
+		MaxRetries: fc.Retry.MaxRetries,
+		Backoff:    problems.duration("retry backoff", fc.Retry.Backoff),
+	}
+
+	window := problems.duration("limiter window", fc.Limiter.Window)
+	if fc.Limiter.Requests < 0 {
+		problems.add("the requests of the limiter can't be negative")
+	}
+	if fc.Limiter.Requests > 0 {
+		if window <= 0 {
+			problems.add("the limiter needs a window")
+		}
+		cfg.Limiter = NewQuotaLimiter(fc.Limiter.Requests, window)
+	}
+
+	return cfg
+}
+
+func (e *ConfigError) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+func (e *ConfigError) duration(name, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		e.add("the %s %q is not a valid duration", name, value)
+		return 0
+	}
+	return d
+}
+
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
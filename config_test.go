@@ -0,0 +1,141 @@
+package news
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig_File(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, "config.json", `{
+		"apiKey": "from-file",
+		"baseURL": "http://localhost:8080/v2/",
+		"timeout": "5s",
+		"headers": {"User-Agent": "bot"},
+		"retry": {"maxRetries": 2, "backoff": "500ms"},
+		"limiter": {"requests": 100, "window": "24h"},
+		"validateOptions": true
+	}`)
+
+	cfg, err := loadConfig(path, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "from-file" || cfg.BaseURL != "http://localhost:8080/v2" || cfg.Timeout != 5*time.Second {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if cfg.Headers["User-Agent"] != "bot" || !cfg.ValidateOptions {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if cfg.Retry != (RetryPolicy{MaxRetries: 2, Backoff: 500 * time.Millisecond}) {
+		t.Fatalf("unexpected retry policy %+v", cfg.Retry)
+	}
+	if l, ok := cfg.Limiter.(*QuotaLimiter); !ok || l.Remaining() != 100 {
+		t.Fatalf("unexpected limiter %+v", cfg.Limiter)
+	}
+}
+
+func TestLoadConfig_EnvWins(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, "config.json", `{"apiKey": "from-file", "timeout": "5s", "headers": {"A": "1"}}`)
+	keyFile := writeFile(t, "key", "from-key-file\n")
+
+	cfg, err := loadConfig(path, env(map[string]string{
+		EnvKeyFile: keyFile,
+		EnvTimeout: "1s",
+		EnvHeaders: "B=2, C=3",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "from-key-file" {
+		t.Fatal("expected the key of the key file but got ", cfg.APIKey)
+	}
+	if cfg.Timeout != time.Second {
+		t.Fatal("expected the timeout of the environment but got ", cfg.Timeout)
+	}
+	if len(cfg.Headers) != 3 || cfg.Headers["C"] != "3" {
+		t.Fatal("expected the headers of both but got ", cfg.Headers)
+	}
+}
+
+func TestLoadConfig_EmptyEnvKey(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, "config.json", `{"apiKeys": ["a", "b"]}`)
+
+	// an empty variable in a compose file doesn't remove the keys
+	cfg, err := loadConfig(path, env(map[string]string{
+		EnvKey:  "  ",
+		EnvKeys: " , ",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Keys == nil || len(cfg.Keys.Stats()) != 2 {
+		t.Fatal("expected the keys of the file")
+	}
+}
+
+func TestLoadConfig_Keys(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadConfig("", env(map[string]string{
+		EnvKeys:        "a, b,c",
+		EnvKeyStrategy: "least-used",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Keys == nil || len(cfg.Keys.Stats()) != 3 || cfg.Keys.strategy != LeastUsed {
+		t.Fatal("expected a pool with 3 keys")
+	}
+}
+
+func TestLoadConfig_Problems(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, "config.json", `{"apiKey": "secret-key", "baseURL": "localhost"}`)
+
+	_, err := loadConfig(path, env(map[string]string{
+		EnvTimeout:     "soon",
+		EnvRetries:     "many",
+		EnvLimitWindow: "1d",
+	}))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	problems := err.(*ConfigError).Problems
+	if len(problems) != 4 {
+		t.Fatalf("expected 4 problems but got %q", problems)
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Fatal("the error must not contain the key")
+	}
+
+	if _, err := loadConfig("", env(nil)); err == nil || !strings.Contains(err.Error(), "no api key") {
+		t.Fatal("expected an error about the missing key but got ", err)
+	}
+
+	path = writeFile(t, "config.json", `{"key": "typo"}`)
+	if _, err := loadConfig(path, env(nil)); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Fatal("expected an error about the unknown field but got ", err)
+	}
+}
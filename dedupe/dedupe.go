@@ -0,0 +1,388 @@
+// Package dedupe groups near-duplicate articles into story clusters.
+// The same wire story is often published by dozens of sources with
+// slightly different titles. Every cluster has one canonical article
+// that can stand in for the whole story, for example in a feed.
+//
+// The similarity is estimated with MinHash over word shingles of the
+// title and description. An Index works incrementally, so articles
+// from a Watcher can be added as they come in.
+package dedupe
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// DefaultThreshold is used if the Threshold of an Index is zero.
+const DefaultThreshold = 0.5
+
+// DefaultShingleSize is used if the ShingleSize of an Index is zero.
+const DefaultShingleSize = 2
+
+// DefaultMinShingles is used if the MinShingles of an Index is zero.
+const DefaultMinShingles = 3
+
+// DefaultMaxAge is used if the MaxAge of an Index is zero.
+const DefaultMaxAge = 72 * time.Hour
+
+// Cluster is a group of articles about the same story.
+type Cluster struct {
+	// ID is unique in the index and counts up from 1 in the
+	// order the clusters were created.
+	ID int
+
+	// Canonical is the article that represents the story.
+	Canonical news.Article
+
+	// Articles contains every article of the cluster (including
+	// the canonical one) in the order they were added.
+	Articles []news.Article
+}
+
+type member struct {
+	title, text       signature
+	hasTitle, hasText bool
+}
+
+type cluster struct {
+	Cluster
+	members []member
+
+	// updated is the time the last article was added.
+	updated time.Time
+}
+
+// bucket is a band of a title or text signature, see bands.
+type bucket struct {
+	text bool
+	band int
+	hash uint64
+}
+
+// Index assigns articles to clusters. The zero value is ready to
+// use. Change the settings before the first call to Add.
+type Index struct {
+	// Threshold is the estimated similarity (between 0 and 1)
+	// at which an article joins a cluster. The bands that select
+	// the clusters to compare are chosen for it, so a low threshold
+	// compares more clusters. Default: DefaultThreshold
+	Threshold float64
+
+	// ShingleSize is the number of consecutive words that are
+	// compared. Default: DefaultShingleSize
+	ShingleSize int
+
+	// SourcePriority contains source ids or names, the best first.
+	// The canonical article is the one from the best source. Sources
+	// that are not in the list come after the listed ones. If the
+	// sources are equal the article published first wins.
+	SourcePriority []string
+
+	// MinShingles is the number of shingles a title (or title and
+	// description) needs to be compared at all. Very short titles
+	// like "Breaking news" would otherwise match each other.
+	// Default: DefaultMinShingles
+	MinShingles int
+
+	// MaxAge is the time after which a cluster that got no new
+	// article is removed from the index. Default: DefaultMaxAge
+	MaxAge time.Duration
+
+	// now can be replaced in tests.
+	now func() time.Time
+
+	mu       sync.Mutex
+	clusters []*cluster
+	urls     map[string]*cluster
+	buckets  map[bucket][]*cluster
+	lastID   int
+	swept    time.Time
+	rows     int // of a band, see bandRows
+}
+
+// Group puts the articles into clusters with the default settings.
+func Group(articles []news.Article) []Cluster {
+	var idx Index
+	for _, a := range articles {
+		idx.Add(a)
+	}
+	return idx.Clusters()
+}
+
+// Add puts the article into the most similar cluster or into a new
+// one if no cluster is similar enough. It returns the cluster and
+// whether it was created for this article. An article with a url
+// that was already added (see news.NormalizeURL) is not added again.
+// Only clusters that share a band of the signature with the article
+// are compared, so Add stays fast for large indexes.
+func (idx *Index) Add(article news.Article) (Cluster, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.urls == nil {
+		idx.urls = make(map[string]*cluster)
+		idx.buckets = make(map[bucket][]*cluster)
+		idx.rows = bandRows(idx.threshold())
+	}
+	now := time.Now()
+	if idx.now != nil {
+		now = idx.now()
+	}
+	idx.sweep(now)
+
+	key := article.CanonicalURL()
+	if c, ok := idx.urls[key]; ok && key != "" {
+		return c.copy(), false
+	}
+
+	m := idx.member(article)
+
+	var best *cluster
+	bestSim := idx.threshold()
+	for _, c := range idx.candidates(m) {
+		if sim := c.similarity(m); sim >= bestSim {
+			best, bestSim = c, sim
+		}
+	}
+
+	created := false
+	if best == nil {
+		idx.lastID++
+		best = &cluster{Cluster: Cluster{
+			ID:        idx.lastID,
+			Canonical: article,
+		}}
+		idx.clusters = append(idx.clusters, best)
+		created = true
+	} else if idx.better(article, best.Canonical) {
+		best.Canonical = article
+	}
+	best.Articles = append(best.Articles, article)
+	best.members = append(best.members, m)
+	best.updated = now
+	for _, b := range m.buckets(idx.rows) {
+		idx.buckets[b] = appendCluster(idx.buckets[b], best)
+	}
+
+	if key != "" {
+		idx.urls[key] = best
+	}
+	return best.copy(), created
+}
+
+// candidates returns the clusters that share a bucket with the
+// member, in the order they were created.
+func (idx *Index) candidates(m member) []*cluster {
+	seen := make(map[*cluster]bool)
+	var result []*cluster
+	for _, b := range m.buckets(idx.rows) {
+		for _, c := range idx.buckets[b] {
+			if !seen[c] {
+				seen[c] = true
+				result = append(result, c)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// sweep removes the clusters that got no article for MaxAge. It
+// only looks at every cluster a few times per MaxAge, not on every
+// Add.
+func (idx *Index) sweep(now time.Time) {
+	maxAge := idx.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	if now.Sub(idx.swept) < maxAge/16 {
+		return
+	}
+	idx.swept = now
+
+	old := make(map[*cluster]bool)
+	var kept []*cluster
+	for _, c := range idx.clusters {
+		if now.Sub(c.updated) > maxAge {
+			old[c] = true
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	if len(old) == 0 {
+		return
+	}
+	idx.clusters = kept
+
+	for key, c := range idx.urls {
+		if old[c] {
+			delete(idx.urls, key)
+		}
+	}
+	for b, clusters := range idx.buckets {
+		var keep []*cluster
+		for _, c := range clusters {
+			if !old[c] {
+				keep = append(keep, c)
+			}
+		}
+		if len(keep) == 0 {
+			delete(idx.buckets, b)
+		} else {
+			idx.buckets[b] = keep
+		}
+	}
+}
+
+// appendCluster adds c to the clusters of a bucket unless it is
+// already in it, the members of a cluster often share a band.
+func appendCluster(clusters []*cluster, c *cluster) []*cluster {
+	for _, o := range clusters {
+		if o == c {
+			return clusters
+		}
+	}
+	return append(clusters, c)
+}
+
+// Clusters returns all clusters in the order they were created.
+// Clusters that got no article for MaxAge are removed by Add.
+func (idx *Index) Clusters() []Cluster {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	result := make([]Cluster, len(idx.clusters))
+	for i, c := range idx.clusters {
+		result[i] = c.copy()
+	}
+	return result
+}
+
+// Len returns the number of clusters.
+func (idx *Index) Len() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	return len(idx.clusters)
+}
+
+func (idx *Index) threshold() float64 {
+	if idx.Threshold == 0 {
+		return DefaultThreshold
+	}
+	return idx.Threshold
+}
+
+func (idx *Index) member(a news.Article) member {
+	size := idx.ShingleSize
+	if size <= 0 {
+		size = DefaultShingleSize
+	}
+
+	min := idx.MinShingles
+	if min <= 0 {
+		min = DefaultMinShingles
+	}
+
+	title := tokenize(cleanTitle(a))
+	text := append(append([]string(nil), title...), tokenize(a.Description)...)
+
+	// too few shingles would match unrelated short titles
+	var m member
+	if s := shingles(title, size); len(s) >= min {
+		m.title, m.hasTitle = newSignature(s)
+	}
+	if s := shingles(text, size); len(s) >= min {
+		m.text, m.hasText = newSignature(s)
+	}
+	return m
+}
+
+// buckets returns the buckets of the signatures of the member.
+func (m *member) buckets(rows int) []bucket {
+	var result []bucket
+	if m.hasTitle {
+		for band, h := range m.title.bands(rows) {
+			result = append(result, bucket{false, band, h})
+		}
+	}
+	if m.hasText {
+		for band, h := range m.text.bands(rows) {
+			result = append(result, bucket{true, band, h})
+		}
+	}
+	return result
+}
+
+// better reports if a should be the canonical article instead of b.
+func (idx *Index) better(a, b news.Article) bool {
+	pa, pb := idx.priority(a.Source), idx.priority(b.Source)
+	if pa != pb {
+		return pa < pb
+	}
+
+	ta, errA := time.Parse(time.RFC3339, a.PublishedAt)
+	tb, errB := time.Parse(time.RFC3339, b.PublishedAt)
+	if errA != nil || errB != nil {
+		// an article without a date never wins
+		return errA == nil && errB != nil
+	}
+	return ta.Before(tb)
+}
+
+func (idx *Index) priority(s news.ArticleSource) int {
+	for i, p := range idx.SourcePriority {
+		if (s.ID != "" && strings.EqualFold(p, s.ID)) || strings.EqualFold(p, s.Name) {
+			return i
+		}
+	}
+	return len(idx.SourcePriority)
+}
+
+// similarity returns the highest similarity to one of the members.
+// The titles are compared on their own too, because some sources
+// leave out the description or write their own.
+func (c *cluster) similarity(m member) float64 {
+	max := 0.0
+	for i := range c.members {
+		o := &c.members[i]
+		if m.hasText && o.hasText {
+			if sim := m.text.similarity(&o.text); sim > max {
+				max = sim
+			}
+		}
+		if m.hasTitle && o.hasTitle {
+			if sim := m.title.similarity(&o.title); sim > max {
+				max = sim
+			}
+		}
+	}
+	return max
+}
+
+func (c *cluster) copy() Cluster {
+	result := c.Cluster
+	result.Articles = append([]news.Article(nil), c.Articles...)
+	return result
+}
+
+// cleanTitle removes the name of the source that a lot of titles
+// end with, for example "Some story - BBC News".
+func cleanTitle(a news.Article) string {
+	title := strings.TrimSpace(a.Title)
+	if a.Source.Name == "" {
+		return title
+	}
+
+	for _, sep := range []string{" - ", " | ", " – ", " — "} {
+		suffix := sep + a.Source.Name
+		if len(title) > len(suffix) && strings.EqualFold(title[len(title)-len(suffix):], suffix) {
+			return title[:len(title)-len(suffix)]
+		}
+	}
+	return title
+}
@@ -0,0 +1,180 @@
+package dedupe
+
+import (
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+func wireStory() []news.Article {
+	return []news.Article{
+		{
+			Source:      news.ArticleSource{Name: "Reuters"},
+			Title:       "Central bank raises interest rates for the third time this year - Reuters",
+			Description: "The central bank raised its key interest rate by a quarter point on Wednesday.",
+			URL:         "https://reuters.com/1",
+			PublishedAt: "2018-03-21T18:00:00Z",
+		},
+		{
+			Source:      news.ArticleSource{ID: "bbc-news", Name: "BBC News"},
+			Title:       "Central bank raises interest rates for third time this year",
+			Description: "The central bank raised its key interest rate by a quarter point.",
+			URL:         "https://bbc.co.uk/2",
+			PublishedAt: "2018-03-21T18:30:00Z",
+		},
+		{
+			Source:      news.ArticleSource{Name: "Some Blog"},
+			Title:       "Central bank raises interest rates for the third time this year",
+			URL:         "https://blog.example.com/3",
+			PublishedAt: "2018-03-21T17:45:00Z",
+		},
+	}
+}
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	articles := append(wireStory(), news.Article{
+		Source:      news.ArticleSource{Name: "TechCrunch"},
+		Title:       "A new phone with a foldable screen was announced",
+		Description: "The phone costs more than any other phone.",
+		URL:         "https://techcrunch.com/4",
+	})
+
+	clusters := Group(articles)
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters but got %d: %+v", len(clusters), clusters)
+	}
+	if len(clusters[0].Articles) != 3 || len(clusters[1].Articles) != 1 {
+		t.Fatal("expected the wire story to be in one cluster")
+	}
+
+	// without a priority the earliest article wins
+	if clusters[0].Canonical.URL != "https://blog.example.com/3" {
+		t.Fatal("expected the earliest article to be canonical but got ", clusters[0].Canonical.URL)
+	}
+}
+
+func TestIndex_SourcePriority(t *testing.T) {
+	t.Parallel()
+
+	idx := Index{SourcePriority: []string{"bbc-news", "Reuters"}}
+	for _, a := range wireStory() {
+		idx.Add(a)
+	}
+
+	c := idx.Clusters()[0]
+	if c.Canonical.URL != "https://bbc.co.uk/2" {
+		t.Fatal("expected the article of the best source to be canonical but got ", c.Canonical.URL)
+	}
+}
+
+func TestIndex_Threshold(t *testing.T) {
+	t.Parallel()
+
+	idx := Index{Threshold: 0.99}
+	for _, a := range wireStory() {
+		idx.Add(a)
+	}
+	if idx.Len() < 2 {
+		t.Fatal("expected a strict threshold to keep the slightly different titles apart")
+	}
+}
+
+func TestIndex_LowThreshold(t *testing.T) {
+	t.Parallel()
+
+	// the jaccard similarity of the shingles is 5/13 = 0.38, below
+	// the 0.42 at which bands of 4 rows find a candidate
+	a := news.Article{Title: "alpha beta gamma delta epsilon zeta eta theta iota kappa"}
+	b := news.Article{Title: "alpha beta gamma delta epsilon zeta lambda mu nu xi"}
+
+	idx := Index{Threshold: 0.3}
+	idx.Add(a)
+	if _, created := idx.Add(b); created {
+		t.Fatal("expected the article to join the cluster with a low threshold")
+	}
+}
+
+func TestIndex_Incremental(t *testing.T) {
+	t.Parallel()
+
+	story := wireStory()
+
+	var idx Index
+	first, created := idx.Add(story[0])
+	if !created || first.ID != 1 {
+		t.Fatal("expected a new cluster for the first article")
+	}
+
+	second, created := idx.Add(story[1])
+	if created || second.ID != first.ID || len(second.Articles) != 2 {
+		t.Fatal("expected the second article to join the existing cluster")
+	}
+
+	// the same url is not added twice
+	again, created := idx.Add(story[1])
+	if created || len(again.Articles) != 2 {
+		t.Fatal("expected the duplicate url to be ignored")
+	}
+
+	other, created := idx.Add(news.Article{Title: "Something completely different", URL: "x"})
+	if !created || other.ID != 2 {
+		t.Fatal("expected a new cluster for a different story")
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	t.Parallel()
+
+	a := news.Article{Title: "Some story - BBC News", Source: news.ArticleSource{Name: "BBC News"}}
+	if title := cleanTitle(a); title != "Some story" {
+		t.Fatal("expected the source name to be removed but got ", title)
+	}
+
+	a.Source.Name = "CNN"
+	if title := cleanTitle(a); title != "Some story - BBC News" {
+		t.Fatal("expected the title to stay the same but got ", title)
+	}
+}
+
+func TestIndex_ShortTitles(t *testing.T) {
+	t.Parallel()
+
+	clusters := Group([]news.Article{
+		{Title: "Breaking news", URL: "https://a.example.com/1"},
+		{Title: "Breaking news", URL: "https://b.example.com/2"},
+	})
+	if len(clusters) != 2 {
+		t.Fatal("expected titles with too few shingles to stay apart but got ", len(clusters))
+	}
+}
+
+func TestIndex_MaxAge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2018, 3, 21, 18, 0, 0, 0, time.UTC)
+	idx := Index{MaxAge: time.Hour, now: func() time.Time { return now }}
+
+	story := wireStory()
+	first, _ := idx.Add(story[0])
+	now = now.Add(30 * time.Minute)
+	if c, created := idx.Add(story[1]); created || c.ID != first.ID {
+		t.Fatal("expected the article to join the recent cluster")
+	}
+
+	now = now.Add(2 * time.Hour)
+	c, created := idx.Add(story[2])
+	if !created || c.ID == first.ID {
+		t.Fatal("expected the old cluster to be gone")
+	}
+	if idx.Len() != 1 {
+		t.Fatal("expected only the new cluster but got ", idx.Len())
+	}
+
+	// the url of the removed cluster can be added again
+	if _, created := idx.Add(story[0]); created {
+		t.Fatal("expected the article to join the new cluster")
+	}
+}
@@ -0,0 +1,134 @@
+package dedupe
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// numHashes is the length of a signature. The error of the
+// estimated similarity is about 1/sqrt(numHashes).
+const numHashes = 128
+
+// signature is the MinHash of the shingles of an article. The share
+// of equal positions in two signatures estimates the Jaccard
+// similarity of the two shingle sets.
+type signature [numHashes]uint64
+
+// seeds are the different hash functions, derived from one fixed
+// start value so that signatures are the same in every run.
+var seeds = func() [numHashes]uint64 {
+	var s [numHashes]uint64
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range s {
+		x = mix(x)
+		s[i] = x
+	}
+	return s
+}()
+
+// mix is the finalizer of splitmix64.
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// newSignature returns the MinHash of the shingles. It returns
+// false if there are no shingles.
+func newSignature(shingles []string) (signature, bool) {
+	var sig signature
+	if len(shingles) == 0 {
+		return sig, false
+	}
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+
+	for _, s := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		base := h.Sum64()
+
+		for i := range sig {
+			if v := mix(base ^ seeds[i]); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig, true
+}
+
+// The signature is split into bands of rows for locality sensitive
+// hashing. Two signatures are only compared if at least one band is
+// equal. With b bands of r rows that happens with a probability of
+// 1-(1-s^r)^b for a similarity s, so fewer rows find less similar
+// articles but compare more clusters.
+//
+// bandRows returns the most rows for which two signatures with the
+// threshold similarity still share a band with minRecall. For
+// example 3 rows (42 bands) for 0.5 and 2 rows (64 bands) for 0.3.
+func bandRows(threshold float64) int {
+	const minRecall = 0.99
+
+	rows := 1
+	for r := 2; r <= numHashes; r++ {
+		bands := numHashes / r
+		recall := 1 - math.Pow(1-math.Pow(threshold, float64(r)), float64(bands))
+		if !(recall >= minRecall) {
+			break
+		}
+		rows = r
+	}
+	return rows
+}
+
+// bands returns a hash of every band of the signature.
+func (sig *signature) bands(rows int) []uint64 {
+	result := make([]uint64, numHashes/rows)
+	for b := range result {
+		h := uint64(b)
+		for _, v := range sig[b*rows : (b+1)*rows] {
+			h = mix(h ^ v)
+		}
+		result[b] = h
+	}
+	return result
+}
+
+// similarity estimates the Jaccard similarity of the two sets.
+func (sig *signature) similarity(other *signature) float64 {
+	equal := 0
+	for i := range sig {
+		if sig[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / numHashes
+}
+
+// tokenize splits the text into lowercase words.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// shingles returns the groups of size consecutive words. Texts
+// shorter than size are one shingle.
+func shingles(words []string, size int) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) <= size {
+		return []string{strings.Join(words, " ")}
+	}
+
+	result := make([]string, 0, len(words)-size+1)
+	for i := 0; i+size <= len(words); i++ {
+		result = append(result, strings.Join(words[i:i+size], " "))
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package dedupe
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSignature_Similarity(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newSignature([]string{"a", "b", "c", "d"})
+	b, _ := newSignature([]string{"a", "b", "c", "e"})
+	c, _ := newSignature([]string{"x", "y", "z"})
+
+	if sim := a.similarity(&a); sim != 1 {
+		t.Fatal("expected a similarity of 1 but got ", sim)
+	}
+	// the jaccard similarity is 3/5
+	if sim := a.similarity(&b); math.Abs(sim-0.6) > 0.15 {
+		t.Fatal("expected about 0.6 but got ", sim)
+	}
+	if sim := a.similarity(&c); sim > 0.1 {
+		t.Fatal("expected about 0 but got ", sim)
+	}
+
+	if _, ok := newSignature(nil); ok {
+		t.Fatal("expected no signature without shingles")
+	}
+}
+
+func TestShingles(t *testing.T) {
+	t.Parallel()
+
+	s := shingles(tokenize("The quick, brown fox!"), 2)
+	if len(s) != 3 || s[0] != "the quick" || s[2] != "brown fox" {
+		t.Fatalf("unexpected shingles: %q", s)
+	}
+
+	s = shingles([]string{"short"}, 2)
+	if len(s) != 1 || s[0] != "short" {
+		t.Fatalf("expected the short text as one shingle but got %q", s)
+	}
+}
+
+func TestBandRows(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		threshold float64
+		rows      int
+	}{
+		{0.2, 1},
+		{0.3, 2},
+		{0.5, 3},
+		{0.8, 6},
+	}
+	for _, test := range tests {
+		if rows := bandRows(test.threshold); rows != test.rows {
+			t.Fatal("expected ", test.rows, " rows for ", test.threshold, " but got ", rows)
+		}
+	}
+	if rows := bandRows(0); rows != 1 {
+		t.Fatal("expected 1 row for 0 but got ", rows)
+	}
+}
@@ -21,6 +21,9 @@ type EverythingOptions struct {
 	SortBy   string `url:"sortBy"`
 	Page     int    `url:"page,omitempty"`
 
+	// The number of results per page (20 by default, 100 is the maximum).
+	PageSize int `url:"pageSize,omitempty"`
+
 	APIKey string `url:"apiKey"`
 }
 
@@ -31,14 +34,23 @@ type EverythingOptions struct {
 // This endpoint suits article discovery and analysis, but can be
 // used to retrieve articles for display, too.
 func Everything(opt EverythingOptions) ([]Article, *ResponseInfo, *Exception) {
-	// the base url
-	url := "https://newsapi.org/v2/everything"
+	return defaultClient().Everything(opt)
+}
+
+// Everything is like the package level Everything but uses the
+// settings of the client.
+func (c *Client) Everything(opt EverythingOptions) ([]Article, *ResponseInfo, *Exception) {
+	if opt.APIKey == "" && c.apiKey != "" {
+		opt.APIKey = c.apiKey
+	}
 
-	if opt.APIKey == "" && APIKey != "" {
-		opt.APIKey = APIKey
+	if c.validate {
+		if err := opt.Validate(); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	res, info, err := fetch(url, opt, opt.ForceFreshData)
+	res, info, err := c.fetch("everything", opt, opt.ForceFreshData)
 	if info != nil {
 		info.TotalResults = res.TotalResults
 	}
@@ -8,58 +8,72 @@ import (
 )
 
 func TestEverything_WithCache(t *testing.T) {
-	testCacheHeader(t, false, func() *Exception {
+	t.Parallel()
+
+	testCacheHeader(t, false, func(c *Client) *Exception {
 		opt := EverythingOptions{}
-		_, _, err := Everything(opt)
+		_, _, err := c.Everything(opt)
 		return err
 	})
 }
 func TestEverything_WithoutCache(t *testing.T) {
-	testCacheHeader(t, true, func() *Exception {
+	t.Parallel()
+
+	testCacheHeader(t, true, func(c *Client) *Exception {
 		opt := EverythingOptions{
 			ForceFreshData: true,
 		}
-		_, _, err := Everything(opt)
+		_, _, err := c.Everything(opt)
 		return err
 	})
 }
 func TestEverything_LocalAPIKey(t *testing.T) {
-	testAPIKey(t, "abc", func() *Exception {
+	t.Parallel()
+
+	testAPIKey(t, Config{APIKey: "xyz"}, "abc", func(c *Client) *Exception {
 		opt := EverythingOptions{
 			APIKey: "abc",
 		}
-		_, _, err := Everything(opt)
+		_, _, err := c.Everything(opt)
 		return err
 	})
 }
-func TestEverything_GlobalAPIKey(t *testing.T) {
-	APIKey = "abc"
+func TestEverything_ClientAPIKey(t *testing.T) {
+	t.Parallel()
 
-	testAPIKey(t, "abc", func() *Exception {
+	testAPIKey(t, Config{APIKey: "abc"}, "abc", func(c *Client) *Exception {
 		opt := EverythingOptions{}
-		_, _, err := Everything(opt)
+		_, _, err := c.Everything(opt)
 		return err
 	})
 }
 func TestEverything_Headers(t *testing.T) {
-	Headers = map[string]string{
-		"key":   "value",
-		"key-2": "value-2",
+	t.Parallel()
+
+	cfg := Config{
+		Headers: map[string]string{
+			"key":   "value",
+			"key-2": "value-2",
+		},
 	}
 
 	// with uppercase keys
 	h := map[string]string{
 		"Key":   "value",
 		"Key-2": "value-2",
+
+		"Accept-Encoding": "gzip, deflate",
 	}
-	testHeaders(t, h, func() *Exception {
+	testHeaders(t, cfg, h, func(c *Client) *Exception {
 		opt := EverythingOptions{}
-		_, _, err := Everything(opt)
+		_, _, err := c.Everything(opt)
 		return err
 	})
 }
 
 func TestEverything_Articles(t *testing.T) {
+	t.Parallel()
+
 	json := `
 	{
 		"status":"ok",
@@ -93,19 +107,17 @@ func TestEverything_Articles(t *testing.T) {
 	}
 	`
 
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			return &http.Response{
-				StatusCode: http.StatusOK,
-				Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
-			}, nil
-		},
-	}
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
 
 	opt := EverythingOptions{
 		Query: "trump",
 	}
-	articles, info, err := Everything(opt)
+	articles, info, err := c.Everything(opt)
 
 	if err != nil {
 		t.Fatal(err)
@@ -120,6 +132,8 @@ func TestEverything_Articles(t *testing.T) {
 }
 
 func TestEverything_Error(t *testing.T) {
+	t.Parallel()
+
 	json := `
 	{
 		"status":"error",
@@ -128,19 +142,17 @@ func TestEverything_Error(t *testing.T) {
 	}
 	`
 
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			return &http.Response{
-				StatusCode: http.StatusOK,
-				Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
-			}, nil
-		},
-	}
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
 
 	opt := EverythingOptions{
 		Query: "",
 	}
-	articles, _, err := Everything(opt)
+	articles, _, err := c.Everything(opt)
 
 	if err == nil {
 		t.Fail()
@@ -0,0 +1,97 @@
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache keeps the extracted articles by url. Implement it yourself to
+// keep them somewhere else (database, redis, ...).
+type Cache interface {
+	Get(url string) (*Result, bool, error)
+	Put(url string, res *Result) error
+}
+
+// MemoryCache is a Cache that only lives as long as the process.
+type MemoryCache struct {
+	mu      sync.Mutex
+	results map[string]*Result
+}
+
+// NewMemoryCache creates an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{results: make(map[string]*Result)}
+}
+
+// Get returns the result for the url.
+func (c *MemoryCache) Get(url string) (*Result, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, ok := c.results[url]
+	return res, ok, nil
+}
+
+// Put remembers the result.
+func (c *MemoryCache) Put(url string, res *Result) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results[url] = res
+	return nil
+}
+
+// DirCache is a Cache that writes every result as a json file
+// into a directory, so that it survives a restart.
+type DirCache struct {
+	dir string
+}
+
+// NewDirCache creates the directory if it doesn't exist.
+func NewDirCache(dir string) (*DirCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DirCache{dir: dir}, nil
+}
+
+func (c *DirCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// Get reads the result for the url.
+func (c *DirCache) Get(url string) (*Result, bool, error) {
+	data, err := ioutil.ReadFile(c.path(url))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var res Result
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, false, err
+	}
+	return &res, true, nil
+}
+
+// Put writes the result. It writes to a temporary file first, so
+// that a crash doesn't leave half a file.
+func (c *DirCache) Put(url string, res *Result) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	path := c.path(url)
+	if err := ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,94 @@
+package extract
+
+import (
+	"bytes"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// metaCharset finds <meta charset="..."> and the charset in
+// <meta http-equiv="Content-Type" content="text/html; charset=...">.
+var metaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.-]+)`)
+
+// toUTF8 converts the page to utf-8. The charset comes from the
+// Content-Type header or the meta tags in the first 1024 bytes (like
+// browsers do it). Only the latin charsets are converted, other
+// charsets and pages without a charset that are not valid utf-8 get
+// their invalid bytes replaced.
+func toUTF8(body []byte, contentType string) []byte {
+	charset := ""
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		charset = params["charset"]
+	}
+	if charset == "" {
+		head := body
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+		if m := metaCharset.FindSubmatch(head); m != nil {
+			charset = string(m[1])
+		}
+	}
+
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "l1", "iso8859-1", "windows-1252", "cp1252", "x-cp1252":
+		// browsers read iso-8859-1 as windows-1252
+		return decode8bit(body, &windows1252)
+	case "iso-8859-15", "latin9", "iso8859-15":
+		return decode8bit(body, &iso885915)
+	case "":
+		if !utf8.Valid(body) {
+			return decode8bit(body, &windows1252)
+		}
+	}
+	if utf8.Valid(body) {
+		return body
+	}
+	return bytes.ToValidUTF8(body, []byte("�"))
+}
+
+// decode8bit converts a single byte charset. The table contains the
+// runes of the bytes from 0x80 on.
+func decode8bit(body []byte, table *[128]rune) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(body) + len(body)/8)
+	for _, b := range body {
+		if b < 0x80 {
+			buf.WriteByte(b)
+		} else {
+			buf.WriteRune(table[b-0x80])
+		}
+	}
+	return buf.Bytes()
+}
+
+// latin1 maps every byte to the rune with the same number.
+func latin1() [128]rune {
+	var t [128]rune
+	for i := range t {
+		t[i] = rune(0x80 + i)
+	}
+	return t
+}
+
+var windows1252 = func() [128]rune {
+	t := latin1()
+	// the printable characters in the c1 control range
+	copy(t[:0x20], []rune{
+		'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+		0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+	})
+	return t
+}()
+
+var iso885915 = func() [128]rune {
+	t := latin1()
+	for b, r := range map[byte]rune{
+		0xa4: '€', 0xa6: 'Š', 0xa8: 'š', 0xb4: 'Ž', 0xb8: 'ž', 0xbc: 'Œ', 0xbd: 'œ', 0xbe: 'Ÿ',
+	} {
+		t[b-0x80] = r
+	}
+	return t
+}()
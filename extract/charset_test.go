@@ -0,0 +1,30 @@
+package extract
+
+import (
+	"testing"
+)
+
+func TestToUTF8(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		body        string
+		contentType string
+		expected    string
+	}{
+		{"Gr\xfc\xdfe \x93zitiert\x94", "text/html; charset=ISO-8859-1", "Grüße “zitiert”"},
+		{"Preis: 5 \x80", "text/html; charset=windows-1252", "Preis: 5 €"},
+		{"Preis: 5 \xa4", "text/html; charset=iso-8859-15", "Preis: 5 €"},
+		{"<meta charset=\"iso-8859-1\"><p>M\xfcnchen", "text/html", "<meta charset=\"iso-8859-1\"><p>München"},
+		{"<meta http-equiv=\"Content-Type\" content=\"text/html; charset=windows-1252\"><p>K\xf6ln", "", "<meta http-equiv=\"Content-Type\" content=\"text/html; charset=windows-1252\"><p>Köln"},
+		// without a charset invalid utf-8 is read as windows-1252
+		{"K\xf6ln", "text/html", "Köln"},
+		{"Köln", "text/html; charset=utf-8", "Köln"},
+		{"Köln \xff", "text/html; charset=utf-8", "Köln �"},
+	}
+	for _, test := range tests {
+		if out := string(toUTF8([]byte(test.body), test.contentType)); out != test.expected {
+			t.Errorf("toUTF8(%q, %q) = %q, expected %q", test.body, test.contentType, out, test.expected)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Package extract fetches the page of an article and extracts the
+// full text, because the api only returns the first characters in
+// Content.
+//
+// The main text is found like the readability mode of browsers do it:
+// the elements with the most paragraphs of text (and the fewest
+// links) win. The byline, the lead image and the published time come
+// from the meta tags, json-ld and the html. The Extractor respects
+// robots.txt, waits between two requests to the same host and caches
+// the results.
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// The errors of the Extractor.
+var (
+	ErrDisallowed = errors.New("extract: disallowed by robots.txt")
+	ErrNotHTML    = errors.New("extract: the page is not html")
+)
+
+// Result is the extracted article.
+type Result struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Byline    string    `json:"byline,omitempty"`
+	Excerpt   string    `json:"excerpt,omitempty"`
+	Image     string    `json:"image,omitempty"`
+	Published time.Time `json:"published,omitempty"`
+
+	// Text is the main text with the paragraphs separated
+	// by empty lines.
+	Text string `json:"text"`
+}
+
+// Extractor fetches and extracts pages. The zero value is usable.
+type Extractor struct {
+	// Client is used for the requests. Default: a http.Client
+	// with a timeout of 15 seconds
+	Client *http.Client
+
+	// UserAgent is sent with every request and selects the rules
+	// of the robots.txt. Default: DefaultUserAgent
+	UserAgent string
+
+	// Delay is the minimum time between two requests to the same
+	// host. A higher Crawl-delay of the robots.txt wins.
+	// Default: 1 second
+	Delay time.Duration
+
+	// Cache keeps the results. Default: MemoryCache
+	Cache Cache
+
+	// IgnoreRobots disables the robots.txt checks, for example
+	// for your own pages.
+	IgnoreRobots bool
+
+	// MaxBodySize limits the size of a page. Default: 5 MiB
+	MaxBodySize int64
+
+	// now and sleep can be replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	once   sync.Once
+	mu     sync.Mutex
+	next   map[string]time.Time // next free slot per host
+	robots map[string]*robots   // per scheme and host
+}
+
+// DefaultUserAgent is the UserAgent if none is set.
+const DefaultUserAgent = "NewsAPIGoExtract/1.0"
+
+func (e *Extractor) init() {
+	e.once.Do(func() {
+		if e.Client == nil {
+			e.Client = &http.Client{Timeout: 15 * time.Second}
+		}
+		if e.UserAgent == "" {
+			e.UserAgent = DefaultUserAgent
+		}
+		if e.Delay <= 0 {
+			e.Delay = time.Second
+		}
+		if e.Cache == nil {
+			e.Cache = NewMemoryCache()
+		}
+		if e.MaxBodySize <= 0 {
+			e.MaxBodySize = 5 << 20
+		}
+		if e.now == nil {
+			e.now = time.Now
+		}
+		if e.sleep == nil {
+			e.sleep = sleep
+		}
+		e.next = make(map[string]time.Time)
+		e.robots = make(map[string]*robots)
+	})
+}
+
+// Article extracts the page of the article.
+func (e *Extractor) Article(ctx context.Context, a news.Article) (*Result, error) {
+	return e.Extract(ctx, a.URL)
+}
+
+// Extract fetches the page and extracts the article. The results are
+// cached by the canonical url (see news.NormalizeURL).
+func (e *Extractor) Extract(ctx context.Context, rawURL string) (*Result, error) {
+	e.init()
+
+	key := news.NormalizeURL(rawURL)
+	if res, ok, err := e.Cache.Get(key); err != nil {
+		return nil, err
+	} else if ok {
+		return res, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.fetch(ctx, u)
+	if errors.Is(err, ErrDisallowed) {
+		// the error of a redirect is wrapped in a url.Error
+		return nil, ErrDisallowed
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("extract: %s: status code %d", rawURL, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return nil, ErrNotHTML
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, e.MaxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	body = toUTF8(body, resp.Header.Get("Content-Type"))
+
+	// relative urls are resolved against the url after redirects
+	res, err := Parse(bytes.NewReader(body), resp.Request.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := e.Cache.Put(key, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// check returns the delay before a request to the url, after it
+// checked the robots.txt of the host.
+func (e *Extractor) check(ctx context.Context, u *url.URL) (time.Duration, error) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return 0, fmt.Errorf("extract: unsupported url %q", u)
+	}
+	delay := e.Delay
+	if e.IgnoreRobots {
+		return delay, nil
+	}
+
+	rules, err := e.robotsFor(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+	if !rules.allowed(e.UserAgent, u.RequestURI()) {
+		return 0, ErrDisallowed
+	}
+	if d := rules.crawlDelay(e.UserAgent); d > delay {
+		delay = d
+	}
+	return delay, nil
+}
+
+// fetch requests the page. Every redirect is checked like the first
+// url, so a page can't redirect to a disallowed one or around the
+// delay of another host.
+func (e *Extractor) fetch(ctx context.Context, u *url.URL) (*http.Response, error) {
+	delay, err := e.check(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	client := *e.Client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if e.Client.CheckRedirect != nil {
+			if err := e.Client.CheckRedirect(req, via); err != nil {
+				return err
+			}
+		} else if len(via) >= 10 {
+			return errors.New("extract: stopped after 10 redirects")
+		}
+		delay, err := e.check(ctx, req.URL)
+		if err != nil {
+			return err
+		}
+		return e.wait(ctx, req.URL.Host, delay)
+	}
+	return e.get(ctx, &client, u, delay)
+}
+
+// robotsFor fetches the robots.txt of the host once. A missing
+// robots.txt (4xx) allows everything, a server error is an error so
+// that we don't crawl a site that is down.
+func (e *Extractor) robotsFor(ctx context.Context, u *url.URL) (*robots, error) {
+	origin := u.Scheme + "://" + u.Host
+
+	e.mu.Lock()
+	rules, ok := e.robots[origin]
+	e.mu.Unlock()
+	if ok {
+		return rules, nil
+	}
+
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	resp, err := e.get(ctx, e.Client, robotsURL, e.Delay)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		rules = parseRobots(io.LimitReader(resp.Body, 500<<10))
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		rules = &robots{}
+	default:
+		return nil, fmt.Errorf("extract: %s: status code %d", robotsURL, resp.StatusCode)
+	}
+
+	e.mu.Lock()
+	e.robots[origin] = rules
+	e.mu.Unlock()
+	return rules, nil
+}
+
+// get waits for the next free slot of the host and sends the request.
+func (e *Extractor) get(ctx context.Context, client *http.Client, u *url.URL, delay time.Duration) (*http.Response, error) {
+	if err := e.wait(ctx, u.Host, delay); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", e.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	return client.Do(req)
+}
+
+// wait waits for the next free slot of the host. The slot is
+// reserved first, so that concurrent requests to the same host are
+// spread out.
+func (e *Extractor) wait(ctx context.Context, host string, delay time.Duration) error {
+	e.mu.Lock()
+	now := e.now()
+	slot := e.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	e.next[host] = slot.Add(delay)
+	e.mu.Unlock()
+
+	return e.sleep(ctx, slot.Sub(now))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
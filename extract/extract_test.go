@@ -0,0 +1,173 @@
+package extract
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// site serves the fixtures and counts the requests.
+type site struct {
+	mu       sync.Mutex
+	requests map[string]int
+	robots   string
+}
+
+func (s *site) server(t *testing.T) *httptest.Server {
+	article, err := ioutil.ReadFile("testdata/article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.requests = make(map[string]int)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/robots.txt":
+			if s.robots == "" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(s.robots))
+		case "/old":
+			http.Redirect(w, r, "/business/takeover", http.StatusMovedPermanently)
+		case "/moved":
+			http.Redirect(w, r, "/private/page", http.StatusFound)
+		case "/feed.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte("<rss></rss>"))
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(article)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testExtractor records the waits instead of sleeping.
+func testExtractor(waits *[]time.Duration) *Extractor {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &Extractor{
+		now: func() time.Time { return now },
+		sleep: func(ctx context.Context, d time.Duration) error {
+			*waits = append(*waits, d)
+			return nil
+		},
+	}
+}
+
+func TestExtractor_Article(t *testing.T) {
+	t.Parallel()
+
+	s := &site{}
+	srv := s.server(t)
+
+	var waits []time.Duration
+	e := testExtractor(&waits)
+
+	res, err := e.Article(context.Background(), news.Article{URL: srv.URL + "/old?utm_source=feed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.URL != srv.URL+"/business/takeover" || res.Image != srv.URL+"/images/lead.jpg" {
+		t.Fatalf("expected the urls after the redirect but got %+v", res)
+	}
+
+	// the second call is cached, also without the tracking parameter
+	if _, err := e.Extract(context.Background(), srv.URL+"/old"); err != nil {
+		t.Fatal(err)
+	}
+	if s.requests["/old"] != 1 || s.requests["/robots.txt"] != 1 {
+		t.Fatalf("unexpected requests %v", s.requests)
+	}
+
+	// robots.txt, then the page one second later and the
+	// redirect to the same host another second later
+	if len(waits) != 3 || waits[0] != 0 || waits[1] != time.Second || waits[2] != 2*time.Second {
+		t.Fatal("unexpected waits ", waits)
+	}
+}
+
+func TestExtractor_Robots(t *testing.T) {
+	t.Parallel()
+
+	s := &site{robots: "User-agent: *\nDisallow: /private/\nCrawl-delay: 5\n"}
+	srv := s.server(t)
+
+	var waits []time.Duration
+	e := testExtractor(&waits)
+
+	if _, err := e.Extract(context.Background(), srv.URL+"/private/page"); err != ErrDisallowed {
+		t.Fatal("expected ErrDisallowed but got ", err)
+	}
+	if s.requests["/private/page"] != 0 {
+		t.Fatal("expected no request for the disallowed page")
+	}
+
+	e.Extract(context.Background(), srv.URL+"/a")
+	e.Extract(context.Background(), srv.URL+"/b")
+	// robots.txt, then the pages with the crawl delay
+	if len(waits) != 3 || waits[1] != time.Second || waits[2] != 6*time.Second {
+		t.Fatal("unexpected waits ", waits)
+	}
+
+	// a redirect can't lead around the robots.txt
+	if _, err := e.Extract(context.Background(), srv.URL+"/moved"); err != ErrDisallowed {
+		t.Fatal("expected ErrDisallowed for the redirect but got ", err)
+	}
+	if s.requests["/moved"] != 1 || s.requests["/private/page"] != 0 {
+		t.Fatalf("expected no request for the disallowed redirect, got %v", s.requests)
+	}
+
+	e.IgnoreRobots = true
+	if _, err := e.Extract(context.Background(), srv.URL+"/private/page"); err != nil {
+		t.Fatal("expected the page without the robots.txt checks but got ", err)
+	}
+}
+
+func TestExtractor_NotHTML(t *testing.T) {
+	t.Parallel()
+
+	srv := (&site{}).server(t)
+	e := &Extractor{IgnoreRobots: true}
+
+	if _, err := e.Extract(context.Background(), srv.URL+"/feed.xml"); err != ErrNotHTML {
+		t.Fatal("expected ErrNotHTML but got ", err)
+	}
+}
+
+func TestDirCache(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	c, err := NewDirCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := c.Get("https://example.com/a"); ok || err != nil {
+		t.Fatal("expected an empty cache")
+	}
+
+	res := &Result{URL: "https://example.com/a", Title: "A", Text: "text", Published: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := c.Put(res.URL, res); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new cache reads the files of the old one
+	c, _ = NewDirCache(dir)
+	got, ok, err := c.Get(res.URL)
+	if err != nil || !ok || got.Title != "A" || !got.Published.Equal(res.Published) {
+		t.Fatalf("unexpected result %+v %v %v", got, ok, err)
+	}
+}
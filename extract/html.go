@@ -0,0 +1,335 @@
+package extract
+
+import (
+	"html"
+	"strings"
+)
+
+// node is an element or (if tag is empty) a text of the document.
+// The parser is forgiving like a browser but much simpler: it knows
+// the void elements, the raw text of script and style, and closes an
+// open paragraph before a block.
+type node struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	parent   *node
+	children []*node
+}
+
+var voidElements = set("area base br col embed hr img input link meta param source track wbr")
+
+var rawTextElements = set("script style textarea title")
+
+// blocks close an open <p>.
+var blocks = set("address article aside blockquote div dl fieldset figure footer form h1 h2 h3 h4 h5 h6 header hr main nav ol p pre section table ul")
+
+// maxDepth limits the nesting of the elements. Deeper elements are
+// added without their content, so that hostile pages with thousands
+// of unclosed tags can't make the end tags and the recursion slow.
+const maxDepth = 256
+
+func set(list string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		m[w] = true
+	}
+	return m
+}
+
+func parseHTML(s string) *node {
+	root := &node{tag: "#root"}
+	cur := root
+	depth := 0
+
+	for i := 0; i < len(s); {
+		lt := strings.IndexByte(s[i:], '<')
+		if lt < 0 {
+			cur.addText(s[i:])
+			break
+		}
+		if lt > 0 {
+			cur.addText(s[i : i+lt])
+		}
+		i += lt
+		rest := s[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return root
+			}
+			i += 4 + end + 3
+
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			i += end + 1
+
+		case strings.HasPrefix(rest, "</"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			name := strings.ToLower(strings.TrimSpace(rest[2:end]))
+			if space := strings.IndexAny(name, " \t\n"); space >= 0 {
+				name = name[:space]
+			}
+			i += end + 1
+
+			// an end tag without a start tag is ignored
+			up := 1
+			for n := cur; n != root; n = n.parent {
+				if n.tag == name {
+					cur = n.parent
+					depth -= up
+					break
+				}
+				up++
+			}
+
+		case len(rest) > 1 && isLetter(rest[1]):
+			name, attrs, selfClosing, n := parseTag(rest)
+			i += n
+
+			if cur.tag == "p" && blocks[name] {
+				cur = cur.parent
+				depth--
+			}
+			if name == "li" && cur.tag == "li" {
+				cur = cur.parent
+				depth--
+			}
+			el := &node{tag: name, attrs: attrs, parent: cur}
+			cur.children = append(cur.children, el)
+
+			if rawTextElements[name] && !selfClosing {
+				end := indexFold(s[i:], "</"+name)
+				if end < 0 {
+					end = len(s) - i
+				}
+				text := s[i : i+end]
+				if name == "title" || name == "textarea" {
+					text = html.UnescapeString(text)
+				}
+				el.children = append(el.children, &node{text: text, parent: el})
+				i += end
+				if close := strings.IndexByte(s[i:], '>'); close >= 0 {
+					i += close + 1
+				}
+				continue
+			}
+			if !voidElements[name] && !selfClosing && depth < maxDepth {
+				cur = el
+				depth++
+			}
+
+		default:
+			cur.addText("<")
+			i++
+		}
+	}
+	return root
+}
+
+// parseTag reads a start tag and returns the number of bytes.
+func parseTag(s string) (name string, attrs map[string]string, selfClosing bool, n int) {
+	i := 1
+	for i < len(s) && !isSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	name = strings.ToLower(s[1:i])
+	attrs = make(map[string]string)
+
+	for i < len(s) {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			return name, attrs, selfClosing, i + 1
+		}
+		if s[i] == '/' {
+			selfClosing = true
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		key := strings.ToLower(s[start:i])
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+
+		var value string
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					end = len(s) - i - 1
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+		if _, ok := attrs[key]; !ok && key != "" {
+			attrs[key] = html.UnescapeString(value)
+		}
+		selfClosing = false
+	}
+	return name, attrs, selfClosing, len(s)
+}
+
+func (n *node) addText(text string) {
+	n.children = append(n.children, &node{text: html.UnescapeString(text), parent: n})
+}
+
+func (n *node) attr(key string) string {
+	return n.attrs[key]
+}
+
+// walk calls fn for every element below n. If fn returns false the
+// children of the element are skipped.
+func (n *node) walk(fn func(*node) bool) {
+	for _, c := range n.children {
+		if c.tag != "" && fn(c) {
+			c.walk(fn)
+		}
+	}
+}
+
+// find returns the first element for which fn returns true.
+func (n *node) find(fn func(*node) bool) *node {
+	var found *node
+	n.walk(func(c *node) bool {
+		if found == nil && fn(c) {
+			found = c
+		}
+		return found == nil
+	})
+	return found
+}
+
+// removeAll removes the elements from their parents. Every parent is
+// filtered once, so that removing many siblings isn't quadratic.
+func removeAll(nodes []*node) {
+	removed := make(map[*node]bool, len(nodes))
+	for _, n := range nodes {
+		removed[n] = true
+	}
+	for _, n := range nodes {
+		parent := n.parent
+		if !removed[n] || parent == nil {
+			continue
+		}
+		kept := parent.children[:0]
+		for _, c := range parent.children {
+			if removed[c] {
+				delete(removed, c)
+				continue
+			}
+			kept = append(kept, c)
+		}
+		parent.children = kept
+	}
+}
+
+// textContent returns the text below n with collapsed whitespace.
+func (n *node) textContent() string {
+	var b strings.Builder
+	var collect func(*node)
+	collect = func(n *node) {
+		for _, c := range n.children {
+			if c.tag == "" {
+				b.WriteString(c.text)
+			} else if c.tag == "br" {
+				b.WriteByte(' ')
+			} else if blocks[c.tag] || c.tag == "li" || c.tag == "td" {
+				b.WriteByte(' ')
+				collect(c)
+				b.WriteByte(' ')
+			} else if c.tag != "script" && c.tag != "style" {
+				collect(c)
+			}
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// length is the text below an element without the whitespace. The
+// links are the part of it inside of <a>.
+type length struct {
+	text, links int
+}
+
+// measure counts the text of every element below n in one pass. The
+// scoring asks for the text of nested elements, which would be
+// quadratic with textContent.
+func measure(n *node) map[*node]length {
+	m := make(map[*node]length)
+	var count func(n *node, link bool) length
+	count = func(n *node, link bool) length {
+		var l length
+		for _, c := range n.children {
+			var cl length
+			switch {
+			case c.tag == "":
+				for i := 0; i < len(c.text); i++ {
+					if !isSpace(c.text[i]) {
+						cl.text++
+					}
+				}
+				if link {
+					cl.links = cl.text
+				}
+			case c.tag != "script" && c.tag != "style":
+				cl = count(c, link || c.tag == "a")
+			}
+			l.text += cl.text
+			l.links += cl.links
+		}
+		m[n] = l
+		return l
+	}
+	count(n, n.tag == "a")
+	return m
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexFold is strings.Index without case for an ascii substr. It
+// doesn't lowercase s, so that the index fits s.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,56 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	t.Parallel()
+
+	doc := parseHTML(`<div class="a" data-x='1 > 0'><p>One<p>Two &amp; <b>three</b><br>four</div>` +
+		`<script>if (a < b) { document.write("</div>") }</script><ul><li>x<li>y</ul></p>`)
+
+	div := doc.find(func(n *node) bool { return n.tag == "div" })
+	if div == nil || div.attr("class") != "a" || div.attr("data-x") != "1 > 0" {
+		t.Fatalf("unexpected div %+v", div)
+	}
+
+	var paragraphs []string
+	div.walk(func(n *node) bool {
+		if n.tag == "p" {
+			paragraphs = append(paragraphs, n.textContent())
+		}
+		return true
+	})
+	if len(paragraphs) != 2 || paragraphs[0] != "One" || paragraphs[1] != "Two & three four" {
+		t.Fatalf("expected the open p to be closed by the next one but got %q", paragraphs)
+	}
+
+	script := doc.find(func(n *node) bool { return n.tag == "script" })
+	if script == nil || script.parent != doc || len(script.children) != 1 {
+		t.Fatal("expected the script as raw text")
+	}
+
+	ul := doc.find(func(n *node) bool { return n.tag == "ul" })
+	if ul == nil || len(ul.children) != 2 || ul.textContent() != "x y" {
+		t.Fatalf("expected 2 list items but got %+v", ul)
+	}
+}
+
+func TestParseHTML_MaxDepth(t *testing.T) {
+	t.Parallel()
+
+	doc := parseHTML(strings.Repeat("<b>", 1000) + "x" + strings.Repeat("</b>", 1000) + "<p>after</p>")
+
+	depth := 0
+	for n := doc; len(n.children) > 0 && n.children[0].tag == "b"; n = n.children[0] {
+		depth++
+	}
+	if depth > maxDepth+1 {
+		t.Fatal("expected the nesting to be limited but got ", depth)
+	}
+	if p := doc.find(func(n *node) bool { return n.tag == "p" }); p == nil || p.parent != doc {
+		t.Fatal("expected the end tags to close the limited elements")
+	}
+}
@@ -0,0 +1,364 @@
+package extract
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNoContent is returned if the page has no main text.
+var ErrNoContent = errors.New("extract: no main text found")
+
+var (
+	// elements that are never part of the main text
+	removeTags = set("script style noscript nav aside form iframe svg button select template footer figcaption")
+
+	unlikely = regexp.MustCompile(`(?i)comment|share|related|sidebar|footer|menu|banner|promo|social|cookie|newsletter|subscribe|popup|advert|sponsor|breadcrumb|widget`)
+	likely   = regexp.MustCompile(`(?i)article|body|content|main|post|story|text|entry`)
+	positive = regexp.MustCompile(`(?i)article|body|content|entry|main|post|story|text`)
+	negative = regexp.MustCompile(`(?i)comment|footer|sidebar|share|related|promo|sponsor|widget|nav|menu|teaser`)
+
+	bylinePrefix = regexp.MustCompile(`(?i)^(by|von)\s+`)
+)
+
+// paragraphs are the elements that make up the text.
+var paragraphs = set("p pre blockquote h2 h3 h4 h5 h6 li")
+
+// Parse extracts the main text and the metadata from the html.
+// pageURL is used to resolve relative image urls.
+func Parse(r io.Reader, pageURL string) (*Result, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc := parseHTML(string(data))
+
+	res := &Result{URL: pageURL}
+	metadata(doc, res)
+	clean(doc)
+
+	lengths := measure(doc)
+	top := topCandidate(doc, lengths)
+	if top == nil {
+		return nil, ErrNoContent
+	}
+	res.Text = text(top, lengths)
+	if res.Text == "" {
+		return nil, ErrNoContent
+	}
+
+	if res.Image == "" {
+		if img := top.find(func(n *node) bool { return n.tag == "img" && n.attr("src") != "" }); img != nil {
+			res.Image = img.attr("src")
+		}
+	}
+	res.Image = resolve(pageURL, res.Image)
+	return res, nil
+}
+
+// metadata reads the meta tags, json-ld and time elements. It runs
+// before clean, because that removes the scripts.
+func metadata(doc *node, res *Result) {
+	meta := make(map[string]string)
+	doc.walk(func(n *node) bool {
+		if n.tag == "meta" {
+			key := strings.ToLower(n.attr("property"))
+			if key == "" {
+				key = strings.ToLower(n.attr("name"))
+			}
+			if key == "" {
+				key = strings.ToLower(n.attr("itemprop"))
+			}
+			if _, ok := meta[key]; !ok && key != "" {
+				meta[key] = strings.TrimSpace(n.attr("content"))
+			}
+		}
+		return true
+	})
+	ld := jsonLD(doc)
+
+	res.Title = first(meta["og:title"], meta["twitter:title"], ld.Headline)
+	if res.Title == "" {
+		if t := doc.find(func(n *node) bool { return n.tag == "title" }); t != nil {
+			res.Title = t.textContent()
+		}
+	}
+	res.Excerpt = first(meta["og:description"], meta["description"], meta["twitter:description"])
+	res.Image = first(meta["og:image"], meta["twitter:image"], ld.image())
+
+	byline := first(meta["author"], ld.author())
+	if strings.HasPrefix(byline, "http") {
+		byline = ""
+	}
+	if byline == "" {
+		if n := doc.find(isByline); n != nil {
+			byline = n.textContent()
+		}
+	}
+	res.Byline = bylinePrefix.ReplaceAllString(byline, "")
+
+	published := first(meta["article:published_time"], meta["datepublished"], ld.DatePublished)
+	if published == "" {
+		if n := doc.find(func(n *node) bool { return n.tag == "time" && n.attr("datetime") != "" }); n != nil {
+			published = n.attr("datetime")
+		}
+	}
+	res.Published = parseTime(published)
+}
+
+func isByline(n *node) bool {
+	if n.attr("rel") == "author" || n.attr("itemprop") == "author" {
+		return true
+	}
+	class := strings.ToLower(n.attr("class") + " " + n.attr("id"))
+	return strings.Contains(class, "byline") || strings.Contains(class, "author")
+}
+
+// linkedData is the part of schema.org/NewsArticle that we use.
+type linkedData struct {
+	Type          interface{}  `json:"@type"`
+	Headline      string       `json:"headline"`
+	DatePublished string       `json:"datePublished"`
+	Author        interface{}  `json:"author"`
+	Image         interface{}  `json:"image"`
+	Graph         []linkedData `json:"@graph"`
+}
+
+func jsonLD(doc *node) linkedData {
+	var found linkedData
+	doc.walk(func(n *node) bool {
+		if n.tag != "script" {
+			return true
+		}
+		if !strings.Contains(n.attr("type"), "ld+json") || found.DatePublished != "" {
+			return false
+		}
+
+		var items []linkedData
+		content := strings.TrimSpace(n.textContent())
+		if strings.HasPrefix(content, "[") {
+			json.Unmarshal([]byte(content), &items)
+		} else {
+			var item linkedData
+			if json.Unmarshal([]byte(content), &item) == nil {
+				items = append(append(items, item), item.Graph...)
+			}
+		}
+		for _, item := range items {
+			if item.DatePublished != "" || item.Headline != "" {
+				found = item
+				break
+			}
+		}
+		return false
+	})
+	return found
+}
+
+func (ld linkedData) author() string {
+	return name(ld.Author)
+}
+
+func (ld linkedData) image() string {
+	switch v := ld.Image.(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			return linkedData{Image: v[0]}.image()
+		}
+	case map[string]interface{}:
+		s, _ := v["url"].(string)
+		return s
+	}
+	return ""
+}
+
+// name reads a string, a {"name": ...} object or a list of them.
+func name(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		s, _ := v["name"].(string)
+		return s
+	case []interface{}:
+		var names []string
+		for _, item := range v {
+			if s := name(item); s != "" {
+				names = append(names, s)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// clean removes the elements that are not part of the main text.
+func clean(doc *node) {
+	lengths := measure(doc)
+	var remove []*node
+	doc.walk(func(n *node) bool {
+		if removeTags[n.tag] {
+			remove = append(remove, n)
+			return false
+		}
+		if n.tag == "body" || n.tag == "article" || n.tag == "main" {
+			return true
+		}
+		// the byline is already in the metadata
+		if isByline(n) && lengths[n].text < 100 {
+			remove = append(remove, n)
+			return false
+		}
+		class := n.attr("class") + " " + n.attr("id")
+		if unlikely.MatchString(class) && !likely.MatchString(class) {
+			remove = append(remove, n)
+			return false
+		}
+		return true
+	})
+	removeAll(remove)
+}
+
+// topCandidate scores the parents of the paragraphs by the amount of
+// text and returns the best one.
+func topCandidate(doc *node, lengths map[*node]length) *node {
+	scores := make(map[*node]float64)
+	var candidates []*node
+
+	add := func(n *node, score float64) {
+		if n == nil || n.tag == "#root" {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	doc.walk(func(n *node) bool {
+		if n.tag != "p" && n.tag != "pre" {
+			return true
+		}
+		text := n.textContent()
+		if len(text) < 25 {
+			return false
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		add(n.parent, score)
+		if n.parent != nil {
+			add(n.parent.parent, score/2)
+		}
+		return false
+	})
+
+	var top *node
+	topScore := 0.0
+	for _, c := range candidates {
+		score := scores[c] * (1 - linkDensity(lengths[c]))
+		if top == nil || score > topScore {
+			top, topScore = c, score
+		}
+	}
+	return top
+}
+
+func initialScore(n *node) float64 {
+	var score float64
+	switch n.tag {
+	case "article":
+		score = 10
+	case "div", "main", "section":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "ul", "ol", "dl", "form":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+
+	class := n.attr("class") + " " + n.attr("id")
+	if negative.MatchString(class) {
+		score -= 25
+	}
+	if positive.MatchString(class) {
+		score += 25
+	}
+	return score
+}
+
+func linkDensity(l length) float64 {
+	if l.text == 0 {
+		return 0
+	}
+	return float64(l.links) / float64(l.text)
+}
+
+// text joins the paragraphs below the candidate.
+func text(top *node, lengths map[*node]length) string {
+	var parts []string
+	top.walk(func(n *node) bool {
+		if !paragraphs[n.tag] {
+			return true
+		}
+		// lists of links are navigation
+		if t := n.textContent(); t != "" && (n.tag != "li" || linkDensity(lengths[n]) < 0.5) {
+			parts = append(parts, t)
+		}
+		return false
+	})
+	return strings.Join(parts, "\n\n")
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func resolve(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package extract
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseFixture(t *testing.T, name, pageURL string) (*Result, error) {
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return Parse(f, pageURL)
+}
+
+func TestParse_Article(t *testing.T) {
+	t.Parallel()
+
+	res, err := parseFixture(t, "article.html", "https://news.example.com/business/takeover")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Title != "Example Corp buys a competitor" || res.Byline != "Jane Doe" || res.Excerpt != "The deal is worth €2 billion." {
+		t.Fatalf("unexpected metadata %+v", res)
+	}
+	if res.Image != "https://news.example.com/images/lead.jpg" {
+		t.Fatal("expected the resolved og:image but got ", res.Image)
+	}
+	if !res.Published.Equal(time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Fatal("unexpected published time ", res.Published)
+	}
+
+	paragraphs := strings.Split(res.Text, "\n\n")
+	expected := []string{
+		"Example Corp has agreed to buy its largest competitor, the company said on Friday, in a deal worth about 2 billion euros.",
+		"The takeover still needs the approval of the regulators, which is expected in the summer, according to people familiar with the matter.",
+		"What changes for customers",
+		"Customers of both companies will keep their contracts, a spokesperson said. Prices & conditions stay the same until next year.",
+	}
+	if len(paragraphs) != len(expected) {
+		t.Fatalf("expected %d paragraphs but got %q", len(expected), paragraphs)
+	}
+	for i := range expected {
+		if paragraphs[i] != expected[i] {
+			t.Errorf("paragraph %d: expected %q but got %q", i, expected[i], paragraphs[i])
+		}
+	}
+	for _, noise := range []string{"cookies", "Share this", "Related", "Copyright", "By Jane Doe", "not text", "headquarters"} {
+		if strings.Contains(res.Text, noise) {
+			t.Errorf("expected %q to be removed", noise)
+		}
+	}
+}
+
+func TestParse_JSONLD(t *testing.T) {
+	t.Parallel()
+
+	res, err := parseFixture(t, "jsonld.html", "https://zeitung.example.com/zinsen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Title != "Zinsen steigen wieder" || res.Byline != "Max Mustermann, Erika Musterfrau" {
+		t.Fatalf("unexpected metadata %+v", res)
+	}
+	if res.Image != "https://cdn.example.com/zinsen.jpg" || res.Published.IsZero() {
+		t.Fatalf("unexpected metadata %+v", res)
+	}
+	if !strings.HasPrefix(res.Text, "Die Zentralbank") || strings.Contains(res.Text, "Mehr zum Thema") {
+		t.Fatal("unexpected text ", res.Text)
+	}
+}
+
+func TestParse_NoContent(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseFixture(t, "empty.html", "https://example.com/login"); err != ErrNoContent {
+		t.Fatal("expected ErrNoContent but got ", err)
+	}
+}
+
+func TestParse_Hostile(t *testing.T) {
+	t.Parallel()
+
+	pages := map[string]string{
+		"nested":    strings.Repeat("<div><p>Some text, with a comma and enough letters. ", 15000),
+		"end tags":  strings.Repeat("<b>", 20000) + strings.Repeat("</i>", 20000),
+		"siblings":  strings.Repeat("<div class=share>x</div>", 20000),
+		"unclosed":  strings.Repeat("<span><a href=x>link</a>", 50000),
+		"raw text":  strings.Repeat("<script>", 20000),
+		"less than": strings.Repeat("a<1", 100000),
+	}
+	for name, page := range pages {
+		done := make(chan struct{})
+		go func() {
+			Parse(strings.NewReader(page), "https://example.com/")
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: parsing %d bytes took longer than 5 seconds", name, len(page))
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(`<html><head><title>a</title></head><body><article><p>Some text, with a comma.</p></article></body></html>`)
+	f.Add(`<div><p>One<p>Two <a href="/x">link</a><ul><li>x<li>y</ul></div><script>if (a < b) {}</script>`)
+	f.Add(`<b><i></b></i><!-- x --><!doctype html><p/>`)
+
+	f.Fuzz(func(t *testing.T, page string) {
+		res, err := Parse(strings.NewReader(page), "https://example.com/")
+		if err == nil && res.Text == "" {
+			t.Fatal("expected ErrNoContent for an empty text")
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package extract
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots are the rules of a robots.txt.
+// -> https://www.rfc-editor.org/rfc/rfc9309
+type robots struct {
+	groups []*robotsGroup
+}
+
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+	delay  time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+func parseRobots(r io.Reader) *robots {
+	result := &robots{}
+	var group *robotsGroup
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+
+		switch key {
+		case "user-agent":
+			// consecutive user-agent lines share a group
+			if group == nil || !lastWasAgent {
+				group = &robotsGroup{}
+				result.groups = append(result.groups, group)
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			// an empty disallow allows everything
+			if group != nil && value != "" {
+				group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && group != nil {
+				group.delay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+		lastWasAgent = false
+	}
+	return result
+}
+
+// group returns the rules for the user agent. A group matches if
+// one of its agents is the product token of the user agent (the name
+// without the version, ignoring the case), so "googlebot" doesn't
+// match "Googlebot-News". All matching groups are combined, without
+// a match the groups for "*" are used.
+func (r *robots) group(userAgent string) *robotsGroup {
+	token := productToken(userAgent)
+
+	var named, wildcard []*robotsGroup
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				wildcard = append(wildcard, g)
+				break
+			}
+			if token != "" && productToken(agent) == token {
+				named = append(named, g)
+				break
+			}
+		}
+	}
+
+	groups := named
+	if len(groups) == 0 {
+		groups = wildcard
+	}
+	switch len(groups) {
+	case 0:
+		return nil
+	case 1:
+		return groups[0]
+	}
+	merged := &robotsGroup{}
+	for _, g := range groups {
+		merged.rules = append(merged.rules, g.rules...)
+		if g.delay > merged.delay {
+			merged.delay = g.delay
+		}
+	}
+	return merged
+}
+
+// productToken returns the name of a user agent without the version
+// and the comments in lowercase: "NewsBot/1.0 (+url)" is "newsbot".
+func productToken(userAgent string) string {
+	token := strings.ToLower(strings.TrimSpace(userAgent))
+	if i := strings.IndexAny(token, "/ ("); i >= 0 {
+		token = token[:i]
+	}
+	return token
+}
+
+// allowed checks the path (with the query). The longest matching
+// rule wins, allow wins over disallow of the same length.
+func (r *robots) allowed(userAgent, path string) bool {
+	g := r.group(userAgent)
+	if g == nil || path == "/robots.txt" {
+		return true
+	}
+
+	allowed := true
+	longest := -1
+	for _, rule := range g.rules {
+		if !matchRobots(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed = rule.allow
+			longest = len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+func (r *robots) crawlDelay(userAgent string) time.Duration {
+	if g := r.group(userAgent); g != nil {
+		return g.delay
+	}
+	return 0
+}
+
+// matchRobots matches a pattern with * wildcards and a $ at the end.
+func matchRobots(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for _, part := range parts[1:] {
+		i := strings.Index(path[pos:], part)
+		if i < 0 {
+			return false
+		}
+		pos += i + len(part)
+	}
+
+	if !anchored {
+		return true
+	}
+	// the last part has to be at the end
+	last := parts[len(parts)-1]
+	return pos == len(path) || (len(parts) > 1 && strings.HasSuffix(path, last))
+}
@@ -0,0 +1,96 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRobots(t *testing.T) {
+	t.Parallel()
+
+	rules := parseRobots(strings.NewReader(`
+# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: BadBot
+User-agent: NewsAPIGoExtract
+Disallow: /
+Allow: /news/
+Crawl-delay: 0.5
+`))
+
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"OtherBot/1.0", "/news/1", true},
+		{"OtherBot/1.0", "/private/secret", false},
+		{"OtherBot/1.0", "/private/public/page", true},
+		{"OtherBot/1.0", "/files/report.pdf", false},
+		{"OtherBot/1.0", "/files/report.pdf?download=1", true},
+		{"NewsAPIGoExtract/1.0", "/news/1", true},
+		{"NewsAPIGoExtract/1.0", "/sport/1", false},
+		{"NewsAPIGoExtract/1.0", "/robots.txt", true},
+	}
+	for _, test := range tests {
+		if rules.allowed(test.agent, test.path) != test.allowed {
+			t.Errorf("%s %s: expected %v", test.agent, test.path, test.allowed)
+		}
+	}
+
+	if rules.crawlDelay("OtherBot") != 2*time.Second || rules.crawlDelay("NewsAPIGoExtract/1.0") != 500*time.Millisecond {
+		t.Fatal("unexpected crawl delays")
+	}
+	if !parseRobots(strings.NewReader("")).allowed("x", "/anything") {
+		t.Fatal("expected an empty robots.txt to allow everything")
+	}
+}
+
+func TestRobots_Group(t *testing.T) {
+	t.Parallel()
+
+	rules := parseRobots(strings.NewReader(`
+User-agent: News
+Disallow: /news-only/
+
+User-agent: *
+Disallow: /all/
+
+User-agent: newsbot
+Disallow: /a/
+
+User-agent: NEWSBOT/2.0
+Disallow: /b/
+Crawl-delay: 3
+`))
+
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		// both groups of newsbot are combined, the case is ignored
+		{"NewsBot/1.0 (+https://example.com)", "/a/1", false},
+		{"NewsBot/1.0", "/b/1", false},
+		{"NewsBot/1.0", "/all/1", true},
+		// "News" is only a part of the token and doesn't match
+		{"NewsBot/1.0", "/news-only/1", true},
+		{"Newsroom/1.0", "/all/1", false},
+		{"Newsroom/1.0", "/news-only/1", true},
+		{"news", "/news-only/1", false},
+	}
+	for _, test := range tests {
+		if rules.allowed(test.agent, test.path) != test.allowed {
+			t.Errorf("%s %s: expected %v", test.agent, test.path, test.allowed)
+		}
+	}
+	if rules.crawlDelay("newsbot") != 3*time.Second {
+		t.Fatal("expected the crawl delay of the combined groups")
+	}
+}
@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// -> https://datatracker.ietf.org/doc/html/rfc4287
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang    string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomPerson `xml:"author,omitempty"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomSource struct {
+	Title string `xml:"title"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published,omitempty"`
+	Author    *atomPerson `xml:"author,omitempty"`
+	Links     []atomLink  `xml:"link"`
+	Summary   string      `xml:"summary,omitempty"`
+	Source    *atomSource `xml:"source,omitempty"`
+}
+
+// WriteAtom writes the articles as an Atom 1.0 document. The channel
+// needs a Title and one of ID, FeedURL or Link.
+func WriteAtom(w io.Writer, ch Channel, articles []news.Article) error {
+	if err := ch.validate(false); err != nil {
+		return err
+	}
+	if ch.id() == "" {
+		return errors.New("the channel needs an id, feed url or link")
+	}
+
+	updated := ch.updated(articles)
+	feed := atomFeed{
+		Lang:    ch.Language,
+		ID:      ch.id(),
+		Title:   ch.Title,
+		Updated: updated.Format(time.RFC3339),
+		Author:  &atomPerson{Name: ch.Author},
+	}
+	if feed.Author.Name == "" {
+		feed.Author.Name = ch.Title
+	}
+	if ch.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: ch.Link, Rel: "alternate"})
+	}
+	if ch.FeedURL != "" {
+		feed.Links = append(feed.Links, atomLink{Href: ch.FeedURL, Rel: "self", Type: "application/atom+xml"})
+	}
+
+	for _, a := range articles {
+		entry := atomEntry{
+			ID:      itemID(a),
+			Title:   a.Title,
+			Updated: feed.Updated,
+			Summary: a.Description,
+		}
+		if t, ok := publishedAt(a); ok {
+			entry.Updated = t.Format(time.RFC3339)
+			entry.Published = entry.Updated
+		}
+		if name := author(a); name != "" {
+			entry.Author = &atomPerson{Name: name}
+		}
+		if a.URL != "" {
+			entry.Links = append(entry.Links, atomLink{Href: a.URL, Rel: "alternate"})
+		}
+		if a.URLToImage != "" {
+			entry.Links = append(entry.Links, atomLink{
+				Href: a.URLToImage,
+				Rel:  "enclosure",
+				Type: imageType(a.URLToImage),
+			})
+		}
+		if a.Source.Name != "" {
+			entry.Source = &atomSource{Title: a.Source.Name}
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(feed)
+}
@@ -0,0 +1,150 @@
+// Package feed turns articles from the news api into RSS 2.0,
+// Atom 1.0 and JSON Feed 1.1 documents.
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// Channel contains the metadata of the feed itself.
+type Channel struct {
+	// Title is required for every format.
+	Title string
+
+	// Link points to the website the feed belongs to. Required for RSS.
+	Link string
+
+	// Description is required for RSS. Defaults to the title.
+	Description string
+
+	// FeedURL is the url the feed document itself is published at.
+	FeedURL string
+
+	// ID is the permanent identifier of an Atom feed. Defaults
+	// to the FeedURL and then the Link.
+	ID string
+
+	// Author is used for the feed level author of Atom feeds.
+	// Defaults to the title.
+	Author string
+
+	// The 2-letter ISO-639-1 code of the language of the articles.
+	Language string
+
+	// Updated defaults to the newest PublishedAt of the articles.
+	Updated time.Time
+}
+
+// Format is one of the supported feed formats.
+type Format string
+
+// The supported feed formats.
+const (
+	RSS  Format = "rss"
+	Atom Format = "atom"
+	JSON Format = "json"
+)
+
+// Write writes the articles as a feed of the given format.
+func Write(w io.Writer, format Format, ch Channel, articles []news.Article) error {
+	switch format {
+	case RSS:
+		return WriteRSS(w, ch, articles)
+	case Atom:
+		return WriteAtom(w, ch, articles)
+	case JSON:
+		return WriteJSON(w, ch, articles)
+	}
+	return fmt.Errorf("unknown feed format %q", format)
+}
+
+func (ch Channel) validate(needLink bool) error {
+	if ch.Title == "" {
+		return errors.New("the channel title is required")
+	}
+	if needLink && ch.Link == "" {
+		return errors.New("the channel link is required")
+	}
+	return nil
+}
+
+func (ch Channel) id() string {
+	switch {
+	case ch.ID != "":
+		return ch.ID
+	case ch.FeedURL != "":
+		return ch.FeedURL
+	}
+	return ch.Link
+}
+
+func (ch Channel) updated(articles []news.Article) time.Time {
+	if !ch.Updated.IsZero() {
+		return ch.Updated
+	}
+
+	var newest time.Time
+	for _, a := range articles {
+		if t, ok := publishedAt(a); ok && t.After(newest) {
+			newest = t
+		}
+	}
+	if newest.IsZero() {
+		return time.Now().UTC()
+	}
+	return newest
+}
+
+// publishedAt parses the date of the article. The api returns
+// it in the RFC 3339 format but it can also be missing.
+func publishedAt(a news.Article) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, a.PublishedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// itemID returns the permanent id of the article: the url, or a
+// hash of the title and the date if the url is missing. The id must
+// not depend on the position, otherwise readers show old articles
+// as new ones once the feed changes.
+func itemID(a news.Article) string {
+	if a.URL != "" {
+		return a.URL
+	}
+	sum := sha256.Sum256([]byte(a.Title + "\n" + a.PublishedAt))
+	return "urn:sha256:" + hex.EncodeToString(sum[:])
+}
+
+// author returns the author of the article or the name of
+// the source if the author is unknown.
+func author(a news.Article) string {
+	if a.Author != "" {
+		return a.Author
+	}
+	return a.Source.Name
+}
+
+// imageType guesses the mime type of the image from the
+// file extension in the url.
+func imageType(imageURL string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		if t := mime.TypeByExtension(ext); strings.HasPrefix(t, "image/") {
+			return t
+		}
+	}
+	return "image/jpeg"
+}
@@ -0,0 +1,277 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+var channel = Channel{
+	Title:    "Top Headlines",
+	Link:     "https://example.com",
+	FeedURL:  "https://example.com/feed",
+	Language: "en",
+}
+
+var articles = []news.Article{
+	{
+		Source: news.ArticleSource{
+			ID:   "bbc-news",
+			Name: "BBC News",
+		},
+		Author:      "BBC News",
+		Title:       "Title 1",
+		Description: "Description 1",
+		URL:         "http://www.bbc.co.uk/news/1",
+		URLToImage:  "http://www.bbc.co.uk/news/1.png",
+		PublishedAt: "2017-12-18T16:27:39Z",
+	},
+	{
+		Source: news.ArticleSource{
+			Name: "Sueddeutsche.de",
+		},
+		Title: "Title 2",
+		URL:   "http://www.sueddeutsche.de/2",
+	},
+}
+
+func TestRSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteRSS(&buf, channel, articles); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			// both the <link> and the <atom:link> end up in here
+			Links       []string `xml:"link"`
+			Description string   `xml:"description"`
+			Items       []struct {
+				Title     string `xml:"title"`
+				GUID      string `xml:"guid"`
+				PubDate   string `xml:"pubDate"`
+				Creator   string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Publisher string `xml:"http://purl.org/dc/elements/1.1/ publisher"`
+				Enclosure struct {
+					URL    string `xml:"url,attr"`
+					Length string `xml:"length,attr"`
+					Type   string `xml:"type,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != "2.0" {
+		t.Fatal("expected version 2.0 but got ", doc.Version)
+	}
+	if doc.Channel.Title == "" || len(doc.Channel.Links) == 0 || doc.Channel.Links[0] == "" || doc.Channel.Description == "" {
+		t.Fatalf("the channel is missing a required element: %+v", doc.Channel)
+	}
+	if len(doc.Channel.Items) != 2 {
+		t.Fatal("expected 2 items but got ", len(doc.Channel.Items))
+	}
+
+	item := doc.Channel.Items[0]
+	if item.GUID != articles[0].URL {
+		t.Fatal("expected the url as the guid but got ", item.GUID)
+	}
+	if item.PubDate != "Mon, 18 Dec 2017 16:27:39 +0000" {
+		t.Fatal("unexpected pubDate ", item.PubDate)
+	}
+	if item.Creator != "BBC News" || item.Publisher != "BBC News" {
+		t.Fatalf("expected the author and source to be mapped: %+v", item)
+	}
+	if item.Enclosure.URL != articles[0].URLToImage || item.Enclosure.Length != "0" || item.Enclosure.Type != "image/png" {
+		t.Fatalf("unexpected enclosure: %+v", item.Enclosure)
+	}
+	if doc.Channel.Items[1].PubDate != "" {
+		t.Fatal("expected no pubDate for an article without a date")
+	}
+}
+
+func TestRSS_MissingLink(t *testing.T) {
+	err := WriteRSS(&bytes.Buffer{}, Channel{Title: "title"}, articles)
+	if err == nil {
+		t.Fatal("expected an error because the link is required")
+	}
+}
+
+func TestAtom(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAtom(&buf, channel, articles); err != nil {
+		t.Fatal(err)
+	}
+
+	type link struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	}
+	var feed struct {
+		XMLName xml.Name
+		Lang    string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Title   string `xml:"title"`
+			Updated string `xml:"updated"`
+			Author  string `xml:"author>name"`
+			Source  string `xml:"source>title"`
+			Links   []link `xml:"link"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+
+	if feed.XMLName.Space != "http://www.w3.org/2005/Atom" {
+		t.Fatal("unexpected namespace ", feed.XMLName.Space)
+	}
+	if feed.ID == "" || feed.Title == "" || feed.Updated == "" {
+		t.Fatalf("the feed is missing a required element: %+v", feed)
+	}
+	if feed.Lang != "en" {
+		t.Fatal("expected the language to be set but got ", feed.Lang)
+	}
+	if feed.Updated != "2017-12-18T16:27:39Z" {
+		t.Fatal("expected the newest article date but got ", feed.Updated)
+	}
+	for _, entry := range feed.Entries {
+		if entry.ID == "" || entry.Title == "" || entry.Updated == "" {
+			t.Fatalf("the entry is missing a required element: %+v", entry)
+		}
+	}
+
+	entry := feed.Entries[0]
+	if entry.Author != "BBC News" || entry.Source != "BBC News" {
+		t.Fatalf("expected the author and source to be mapped: %+v", entry)
+	}
+	expected := []link{
+		{Href: articles[0].URL, Rel: "alternate"},
+		{Href: articles[0].URLToImage, Rel: "enclosure"},
+	}
+	if len(entry.Links) != 2 || entry.Links[0] != expected[0] || entry.Links[1] != expected[1] {
+		t.Fatalf("unexpected links: %+v", entry.Links)
+	}
+
+	// without an author the name of the source is used
+	if feed.Entries[1].Author != "Sueddeutsche.de" {
+		t.Fatal("expected the source as the author but got ", feed.Entries[1].Author)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, channel, articles); err != nil {
+		t.Fatal(err)
+	}
+
+	var feed map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+
+	if feed["version"] != "https://jsonfeed.org/version/1.1" {
+		t.Fatal("unexpected version ", feed["version"])
+	}
+	if feed["title"] != channel.Title {
+		t.Fatal("expected the title to be set")
+	}
+
+	items, ok := feed["items"].([]interface{})
+	if !ok || len(items) != 2 {
+		t.Fatal("expected 2 items but got ", feed["items"])
+	}
+	for _, i := range items {
+		item := i.(map[string]interface{})
+		if item["id"] == "" {
+			t.Fatal("every item needs an id")
+		}
+		if item["content_text"] == nil && item["content_html"] == nil {
+			t.Fatal("every item needs content_text or content_html")
+		}
+	}
+
+	item := items[0].(map[string]interface{})
+	if item["image"] != articles[0].URLToImage {
+		t.Fatal("expected the image to be mapped")
+	}
+	if item["date_published"] != "2017-12-18T16:27:39Z" {
+		t.Fatal("unexpected date ", item["date_published"])
+	}
+	source := item["_source"].(map[string]interface{})
+	if source["name"] != "BBC News" {
+		t.Fatal("expected the source to be mapped")
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, Format("csv"), channel, articles)
+	if err == nil || !strings.Contains(err.Error(), "unknown feed format") {
+		t.Fatal("expected an error about the unknown format but got ", err)
+	}
+}
+
+func TestChannel_Updated(t *testing.T) {
+	updated := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	ch := Channel{Updated: updated}
+
+	if got := ch.updated(articles); !got.Equal(updated) {
+		t.Fatal("expected the channel date to be used but got ", got)
+	}
+}
+
+func TestItemID(t *testing.T) {
+	noURL := news.Article{Title: "Title 3", PublishedAt: "2017-12-18T16:27:39Z"}
+
+	ids := func(articles []news.Article) (string, string) {
+		var atom, jsonFeed bytes.Buffer
+		if err := WriteAtom(&atom, channel, articles); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteJSON(&jsonFeed, channel, articles); err != nil {
+			t.Fatal(err)
+		}
+
+		var a struct {
+			Entries []struct {
+				ID string `xml:"id"`
+			} `xml:"entry"`
+		}
+		xml.Unmarshal(atom.Bytes(), &a)
+		var j struct {
+			Items []struct {
+				ID string `json:"id"`
+			} `json:"items"`
+		}
+		json.Unmarshal(jsonFeed.Bytes(), &j)
+		return a.Entries[len(a.Entries)-1].ID, j.Items[len(j.Items)-1].ID
+	}
+
+	// the id doesn't change with the position in the feed
+	atom1, json1 := ids([]news.Article{noURL})
+	atom2, json2 := ids(append(articles[:2:2], noURL))
+	if atom1 == "" || atom1 != atom2 || json1 != json2 {
+		t.Fatal("expected the same id at every position but got ", atom1, atom2, json1, json2)
+	}
+	if atom1 != json1 {
+		t.Fatal("expected the same id in both formats but got ", atom1, json1)
+	}
+
+	other := noURL
+	other.Title = "Title 4"
+	if id, _ := ids([]news.Article{other}); id == atom1 {
+		t.Fatal("expected another id for another article")
+	}
+}
@@ -0,0 +1,97 @@
+package feed
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// -> https://www.jsonfeed.org/version/1.1/
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url,omitempty"`
+	FeedURL     string     `json:"feed_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Language    string     `json:"language,omitempty"`
+	Authors     []jsonName `json:"authors,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonName struct {
+	Name string `json:"name"`
+}
+
+type jsonItem struct {
+	ID            string      `json:"id"`
+	URL           string      `json:"url,omitempty"`
+	Title         string      `json:"title,omitempty"`
+	ContentText   string      `json:"content_text"`
+	Summary       string      `json:"summary,omitempty"`
+	Image         string      `json:"image,omitempty"`
+	DatePublished string      `json:"date_published,omitempty"`
+	Authors       []jsonName  `json:"authors,omitempty"`
+	Source        *jsonSource `json:"_source,omitempty"`
+}
+
+// jsonSource is a custom extension (they have to start
+// with an underscore) because JSON Feed has no field
+// for the publisher.
+type jsonSource struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// WriteJSON writes the articles as a JSON Feed 1.1 document. The
+// channel needs a Title.
+func WriteJSON(w io.Writer, ch Channel, articles []news.Article) error {
+	if err := ch.validate(false); err != nil {
+		return err
+	}
+
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       ch.Title,
+		HomePageURL: ch.Link,
+		FeedURL:     ch.FeedURL,
+		Description: ch.Description,
+		Language:    ch.Language,
+		Items:       []jsonItem{},
+	}
+	if ch.Author != "" {
+		feed.Authors = []jsonName{{Name: ch.Author}}
+	}
+
+	for _, a := range articles {
+		item := jsonItem{
+			ID:          itemID(a),
+			URL:         a.URL,
+			Title:       a.Title,
+			ContentText: a.Description,
+			Summary:     a.Description,
+			Image:       a.URLToImage,
+		}
+		// every item needs content, the title is the
+		// best thing available if there is no description.
+		if item.ContentText == "" {
+			item.ContentText = a.Title
+		}
+		if t, ok := publishedAt(a); ok {
+			item.DatePublished = t.Format(time.RFC3339)
+		}
+		if name := author(a); name != "" {
+			item.Authors = []jsonName{{Name: name}}
+		}
+		if a.Source.Name != "" {
+			item.Source = &jsonSource{ID: a.Source.ID, Name: a.Source.Name}
+		}
+
+		feed.Items = append(feed.Items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(feed)
+}
@@ -0,0 +1,133 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// -> https://www.rssboard.org/rss-specification
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Atom    string     `xml:"xmlns:atom,attr,omitempty"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string   `xml:"title"`
+	Link          string   `xml:"link"`
+	Description   string   `xml:"description"`
+	Language      string   `xml:"language,omitempty"`
+	LastBuildDate string   `xml:"lastBuildDate"`
+	Generator     string   `xml:"generator"`
+	Self          *rssSelf `xml:"atom:link,omitempty"`
+	Items         []rssItem
+}
+
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	XMLName     xml.Name      `xml:"item"`
+	Title       string        `xml:"title,omitempty"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	Publisher   string        `xml:"dc:publisher,omitempty"`
+	GUID        *rssGUID      `xml:"guid,omitempty"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// WriteRSS writes the articles as an RSS 2.0 document. The channel
+// needs a Title and a Link.
+//
+// RSS only allows email addresses in `author`, so the author is
+// added as `dc:creator` and the source as `dc:publisher` instead.
+func WriteRSS(w io.Writer, ch Channel, articles []news.Article) error {
+	if err := ch.validate(true); err != nil {
+		return err
+	}
+
+	doc := rssDocument{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         ch.Title,
+			Link:          ch.Link,
+			Description:   ch.Description,
+			Language:      ch.Language,
+			LastBuildDate: ch.updated(articles).Format(time.RFC1123Z),
+			Generator:     "News-API-go",
+		},
+	}
+	if doc.Channel.Description == "" {
+		doc.Channel.Description = ch.Title
+	}
+	if ch.FeedURL != "" {
+		doc.Atom = "http://www.w3.org/2005/Atom"
+		doc.Channel.Self = &rssSelf{
+			Href: ch.FeedURL,
+			Rel:  "self",
+			Type: "application/rss+xml",
+		}
+	}
+
+	for _, a := range articles {
+		item := rssItem{
+			Title:       a.Title,
+			Link:        a.URL,
+			Description: a.Description,
+			Creator:     a.Author,
+			Publisher:   a.Source.Name,
+		}
+		// an item needs either a title or a description
+		if item.Title == "" && item.Description == "" {
+			item.Title = a.URL
+		}
+		if a.URL != "" {
+			item.GUID = &rssGUID{Value: a.URL, IsPermaLink: true}
+		} else {
+			item.GUID = &rssGUID{Value: itemID(a)}
+		}
+		if t, ok := publishedAt(a); ok {
+			item.PubDate = t.Format(time.RFC1123Z)
+		}
+		if a.URLToImage != "" {
+			// the size of the image is unknown without downloading
+			// it, 0 is the accepted value for that case.
+			item.Enclosure = &rssEnclosure{
+				URL:    a.URLToImage,
+				Length: 0,
+				Type:   imageType(a.URLToImage),
+			}
+		}
+
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
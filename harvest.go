@@ -0,0 +1,254 @@
+package news
+
+import (
+	"sort"
+	"time"
+)
+
+// Gap is a time window the Harvester could not fetch completely.
+type Gap struct {
+	From time.Time
+	To   time.Time
+
+	// the number of articles the api reported for the window
+	// and how many of them were fetched.
+	TotalResults int
+	Fetched      int
+
+	Reason string
+}
+
+// HarvestResult contains the merged articles of every window.
+type HarvestResult struct {
+	// Articles are deduped by their canonical url and sorted by PublishedAt,
+	// the newest first.
+	Articles []Article
+
+	// Requests is the number of requests that were sent.
+	Requests int
+
+	// Gaps contains the windows that are missing articles. An
+	// empty list means the result is complete.
+	Gaps []Gap
+}
+
+// Harvester gets past the result cap of Everything. Everything only
+// paginates up to a maximum number of results that depends on the
+// plan, so a broad query over a long time only returns the first
+// few articles. The harvester splits the window into smaller windows
+// until every window fits below the cap.
+type Harvester struct {
+	// Client sends the requests. Default: the package level settings
+	Client *Client
+
+	// MaxResults is the number of results that pagination can
+	// reach with your plan. Default: 100
+	MaxResults int
+
+	// PageSize is the number of results per request. Default: 100
+	PageSize int
+
+	// MinWindow is the smallest window that is split further. If a
+	// window this small still has too many results it is reported
+	// as a Gap. Default: 1 minute
+	MinWindow time.Duration
+}
+
+// Harvest fetches every article of the query between opt.From
+// and opt.To. Both are required.
+//
+// Every request goes through Everything and therefore through the
+// limiter of the client. Once the quota is exhausted the remaining windows
+// are reported as gaps.
+func (h Harvester) Harvest(opt EverythingOptions) (*HarvestResult, *Exception) {
+	if h.MaxResults <= 0 {
+		h.MaxResults = 100
+	}
+	if h.PageSize <= 0 {
+		h.PageSize = 100
+	}
+	if h.PageSize > h.MaxResults {
+		h.PageSize = h.MaxResults
+	}
+	if h.MinWindow <= 0 {
+		h.MinWindow = time.Minute
+	}
+	if h.Client == nil {
+		h.Client = defaultClient()
+	}
+
+	from, err := parseDate(opt.From)
+	if err != nil {
+		return nil, &Exception{Code: "[harvest]", Message: "invalid 'From': " + err.Error()}
+	}
+	to, err := parseDate(opt.To)
+	if err != nil {
+		return nil, &Exception{Code: "[harvest]", Message: "invalid 'To': " + err.Error()}
+	}
+	if to.Before(from) {
+		return nil, &Exception{Code: "[harvest]", Message: "'To' is before 'From'"}
+	}
+
+	// sorting by date makes the pages of a window stable
+	if opt.SortBy == "" {
+		opt.SortBy = "publishedAt"
+	}
+	opt.PageSize = h.PageSize
+
+	s := &harvest{
+		Harvester: h,
+		opt:       opt,
+		seen:      make(map[string]bool),
+		result:    &HarvestResult{},
+	}
+	s.window(from, to)
+
+	sort.SliceStable(s.result.Articles, func(i, j int) bool {
+		return s.result.Articles[i].PublishedAt > s.result.Articles[j].PublishedAt
+	})
+	sort.Slice(s.result.Gaps, func(i, j int) bool {
+		return s.result.Gaps[i].From.Before(s.result.Gaps[j].From)
+	})
+
+	return s.result, nil
+}
+
+// harvest is the state of one Harvest call.
+type harvest struct {
+	Harvester
+	opt    EverythingOptions
+	seen   map[string]bool
+	result *HarvestResult
+
+	// stopped is set once the quota is exhausted.
+	stopped *Exception
+}
+
+func (s *harvest) fetch(from, to time.Time, page int) ([]Article, *ResponseInfo, *Exception) {
+	if s.stopped != nil {
+		return nil, nil, s.stopped
+	}
+
+	opt := s.opt
+	opt.From = from.UTC().Format(time.RFC3339)
+	opt.To = to.UTC().Format(time.RFC3339)
+	opt.Page = page
+
+	s.result.Requests++
+	articles, info, err := s.Client.Everything(opt)
+	if err != nil && isQuotaError(err) {
+		s.stopped = err
+	}
+	return articles, info, err
+}
+
+func (s *harvest) add(articles []Article) {
+	for _, a := range articles {
+		key := a.CanonicalURL()
+		if key != "" && s.seen[key] {
+			continue
+		}
+		s.seen[key] = true
+		s.result.Articles = append(s.result.Articles, a)
+	}
+}
+
+func (s *harvest) window(from, to time.Time) {
+	articles, info, err := s.fetch(from, to, 1)
+	if err != nil {
+		s.result.Gaps = append(s.result.Gaps, Gap{From: from, To: to, Reason: err.Error()})
+		return
+	}
+
+	total := info.TotalResults
+	if total > s.MaxResults && to.Sub(from) > s.MinWindow {
+		if oldest, ok := s.oldest(articles, from, to); ok {
+			// the page contains the newest articles of the window,
+			// so only the time before the oldest of them is missing.
+			// The boundary is inclusive for articles of the same second.
+			s.add(articles)
+			s.window(from, oldest)
+			return
+		}
+	}
+
+	mid := from.Add(to.Sub(from) / 2).Truncate(time.Second)
+	if total > s.MaxResults && to.Sub(from) > s.MinWindow && mid.After(from) {
+		// the boundaries are inclusive, the articles that are in
+		// both windows are removed by the dedupe.
+		s.window(from, mid)
+		s.window(mid, to)
+		return
+	}
+
+	s.add(articles)
+	fetched := len(articles)
+
+	// fetch the other pages that pagination can reach
+	reachable := total
+	if reachable > s.MaxResults {
+		reachable = s.MaxResults
+	}
+	for page := 2; fetched < reachable; page++ {
+		articles, _, err := s.fetch(from, to, page)
+		if err != nil {
+			s.result.Gaps = append(s.result.Gaps, Gap{
+				From:         from,
+				To:           to,
+				TotalResults: total,
+				Fetched:      fetched,
+				Reason:       err.Error(),
+			})
+			return
+		}
+		if len(articles) == 0 {
+			break
+		}
+		s.add(articles)
+		fetched += len(articles)
+	}
+
+	if fetched < total {
+		s.result.Gaps = append(s.result.Gaps, Gap{
+			From:         from,
+			To:           to,
+			TotalResults: total,
+			Fetched:      fetched,
+			Reason:       "more results than pagination can reach",
+		})
+	}
+}
+
+// oldest returns the date of the last article of a full page that
+// is sorted by date, if it is inside the window.
+func (s *harvest) oldest(articles []Article, from, to time.Time) (time.Time, bool) {
+	if s.opt.SortBy != "publishedAt" || len(articles) < s.PageSize || len(articles) == 0 {
+		return time.Time{}, false
+	}
+	oldest, err := parseDate(articles[len(articles)-1].PublishedAt)
+	if err != nil || !oldest.After(from) || !oldest.Before(to) {
+		return time.Time{}, false
+	}
+	return oldest, true
+}
+
+// isQuotaError reports wether all following requests would
+// fail as well.
+func isQuotaError(err *Exception) bool {
+	switch err.Code {
+	case "[limiter]", "rateLimited", "apiKeyExhausted", "apiKeyDisabled", "apiKeyInvalid", "apiKeyMissing":
+		return true
+	}
+	return false
+}
+
+// parseDate parses dates in the formats the api accepts.
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05", s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
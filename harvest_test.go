@@ -0,0 +1,190 @@
+package news
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// corpusMock answers Everything requests from a list of articles,
+// like the api it only paginates up to maxResults.
+func corpusMock(corpus []Article, maxResults int) *ClientMock {
+	return &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			q := req.URL.Query()
+			from, _ := time.Parse(time.RFC3339, q.Get("from"))
+			to, _ := time.Parse(time.RFC3339, q.Get("to"))
+			page, _ := strconv.Atoi(q.Get("page"))
+			pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+
+			var matching []Article
+			for _, a := range corpus {
+				published, _ := time.Parse(time.RFC3339, a.PublishedAt)
+				if !published.Before(from) && !published.After(to) {
+					matching = append(matching, a)
+				}
+			}
+			sort.Slice(matching, func(i, j int) bool {
+				return matching[i].PublishedAt > matching[j].PublishedAt
+			})
+
+			res := networkResult{Status: "ok", TotalResults: len(matching)}
+			start, end := (page-1)*pageSize, page*pageSize
+			if end > maxResults {
+				res = networkResult{Status: "error", Code: "maximumResultsReached"}
+			} else {
+				for i := start; i < end && i < len(matching); i++ {
+					res.Articles = append(res.Articles, matching[i])
+				}
+			}
+
+			body, _ := json.Marshal(res)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBuffer(body)),
+			}, nil
+		},
+	}
+}
+
+func testCorpus(n int, start time.Time, step time.Duration) []Article {
+	var corpus []Article
+	for i := 0; i < n; i++ {
+		corpus = append(corpus, Article{
+			URL:         fmt.Sprint("https://example.com/", i),
+			PublishedAt: start.Add(time.Duration(i) * step).Format(time.RFC3339),
+		})
+	}
+	return corpus
+}
+
+func TestHarvest_SplitsWindow(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	corpus := testCorpus(250, start, time.Hour)
+	c := NewClient(Config{HTTPClient: corpusMock(corpus, 100)})
+
+	h := Harvester{Client: c, MaxResults: 100, PageSize: 20}
+	res, err := h.Harvest(EverythingOptions{
+		Query: "bitcoin",
+		From:  start.Format(time.RFC3339),
+		To:    start.Add(250 * time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Articles) != 250 {
+		t.Fatal("expected every article but got ", len(res.Articles))
+	}
+	if len(res.Gaps) != 0 {
+		t.Fatalf("expected no gaps but got %+v", res.Gaps)
+	}
+	if res.Articles[0].URL != "https://example.com/249" {
+		t.Fatal("expected the newest article first but got ", res.Articles[0].URL)
+	}
+	// every first page is used, so a request brings about a
+	// page of new articles
+	if res.Requests > 15 {
+		t.Fatal("expected at most 15 requests but got ", res.Requests)
+	}
+}
+
+func TestHarvest_CanonicalURL(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	corpus := testCorpus(3, start, time.Hour)
+	corpus[2].URL = "https://example.com/1?utm_source=rss"
+	c := NewClient(Config{HTTPClient: corpusMock(corpus, 100)})
+
+	res, err := Harvester{Client: c}.Harvest(EverythingOptions{
+		From: start.Format(time.RFC3339),
+		To:   start.Add(3 * time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Articles) != 2 {
+		t.Fatal("expected the tracking url to be a duplicate but got ", len(res.Articles))
+	}
+}
+
+func TestHarvest_ReportsGaps(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 150 articles within the same second can't be split
+	corpus := testCorpus(150, start, 0)
+	for i := range corpus {
+		corpus[i].PublishedAt = start.Format(time.RFC3339)
+	}
+	c := NewClient(Config{HTTPClient: corpusMock(corpus, 100)})
+
+	h := Harvester{Client: c, MaxResults: 100, PageSize: 50}
+	res, err := h.Harvest(EverythingOptions{
+		From: "2018-01-01",
+		To:   "2018-01-02",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Articles) != 100 {
+		t.Fatal("expected the reachable articles but got ", len(res.Articles))
+	}
+	if len(res.Gaps) != 1 || res.Gaps[0].TotalResults != 150 || res.Gaps[0].Fetched != 100 {
+		t.Fatalf("expected one gap but got %+v", res.Gaps)
+	}
+}
+
+func TestHarvest_Limiter(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	corpus := testCorpus(250, start, time.Hour)
+	c := NewClient(Config{
+		HTTPClient: corpusMock(corpus, 100),
+		Limiter:    NewQuotaLimiter(2, time.Hour),
+	})
+
+	h := Harvester{Client: c, MaxResults: 100, PageSize: 100}
+	res, err := h.Harvest(EverythingOptions{
+		From: start.Format(time.RFC3339),
+		To:   start.Add(250 * time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Gaps) == 0 {
+		t.Fatal("expected gaps because the quota is exhausted")
+	}
+	if res.Requests > 4 {
+		t.Fatal("expected the harvester to stop after the quota is exhausted but got ", res.Requests)
+	}
+	for _, gap := range res.Gaps {
+		if gap.Reason == "" {
+			t.Fatalf("expected a reason for the gap: %+v", gap)
+		}
+	}
+}
+
+func TestHarvest_InvalidDates(t *testing.T) {
+	t.Parallel()
+
+	h := Harvester{Client: NewClient(Config{HTTPClient: &ClientMock{}})}
+	if _, err := h.Harvest(EverythingOptions{From: "yesterday"}); err == nil {
+		t.Fatal("expected an error because of the invalid date")
+	}
+	if _, err := h.Harvest(EverythingOptions{From: "2018-01-02", To: "2018-01-01"}); err == nil {
+		t.Fatal("expected an error because 'To' is before 'From'")
+	}
+}
@@ -0,0 +1,96 @@
+package images
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cached is a file of the cache.
+type cached struct {
+	path string
+	os.FileInfo
+}
+
+// files returns the originals and the thumbnails, without the
+// temporary files of writes that are in progress.
+func (f *Fetcher) files() ([]cached, error) {
+	var result []cached
+	for _, sub := range []string{"originals", "thumbnails"} {
+		infos, err := ioutil.ReadDir(filepath.Join(f.dir, sub))
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.IsDir() || strings.HasPrefix(info.Name(), ".tmp-") {
+				continue
+			}
+			result = append(result, cached{filepath.Join(f.dir, sub, info.Name()), info})
+		}
+	}
+	return result, nil
+}
+
+// touch marks the file as used, the cache removes the files with the
+// oldest modification time first.
+func (f *Fetcher) touch(path string) {
+	now := f.now()
+	os.Chtimes(path, now, now)
+}
+
+// added counts the bytes of a new file and prunes the cache if it
+// grew over MaxCacheSize.
+func (f *Fetcher) added(n int64) {
+	f.mu.Lock()
+	f.size += n
+	full := f.size > f.MaxCacheSize
+	f.mu.Unlock()
+
+	if !full {
+		return
+	}
+	if err := f.prune(); err != nil {
+		f.onError("", err)
+	}
+}
+
+// prune removes the least recently used files until the cache is at
+// 90% of MaxCacheSize, so that it isn't pruned on every write. A
+// removed original is fetched again when a new thumbnail of it is
+// needed.
+func (f *Fetcher) prune() error {
+	f.pruneMu.Lock()
+	defer f.pruneMu.Unlock()
+
+	files, err := f.files()
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, file := range files {
+		size += file.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	limit := f.MaxCacheSize / 10 * 9
+	for _, file := range files {
+		if size <= limit {
+			break
+		}
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		size -= file.Size()
+	}
+
+	f.mu.Lock()
+	f.size = size
+	f.mu.Unlock()
+
+	// urls that were fetched again were appended to the index
+	return f.compact()
+}
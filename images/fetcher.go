@@ -0,0 +1,546 @@
+// Package images downloads the images of articles (URLToImage) and
+// serves thumbnails of them, so that front-ends don't hot-link the
+// images of the publishers.
+//
+// Every image is validated by decoding it. The originals and the
+// thumbnails are stored by the sha256 of the original, so the same
+// image under different urls is stored once. Broken images are
+// recorded and not fetched again until RetryBroken is over. The
+// least recently used files are removed when the directory grows over
+// MaxCacheSize.
+package images
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register the decoders
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// The errors of the Fetcher.
+var (
+	ErrBroken     = errors.New("images: broken image")
+	ErrNoImage    = errors.New("images: the article has no image")
+	ErrPrivateURL = errors.New("images: the host is a private address")
+
+	// ErrRedirect is returned if an image that the handler serves
+	// because of the Hosts redirects to another host.
+	ErrRedirect = errors.New("images: redirect to a host that is not allowed")
+)
+
+// Image is a downloaded and validated image.
+type Image struct {
+	URL string `json:"url"`
+
+	// Hash is the hex encoded sha256 of the original.
+	Hash   string `json:"hash"`
+	Format string `json:"format"` // jpeg, png or gif
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// Broken is a url that couldn't be fetched or decoded.
+type Broken struct {
+	URL   string    `json:"url"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// Fetcher downloads images into a directory. Create it with
+// NewFetcher and change the fields before the first use.
+type Fetcher struct {
+	// Client is used for the downloads. Default: a http.Client
+	// with a timeout of 15 seconds that refuses private addresses
+	// (see AllowPrivate)
+	Client *http.Client
+
+	// AllowPrivate allows urls of private and loopback addresses.
+	// Keep it disabled if the urls come from the api, otherwise
+	// the handler can be used to reach the internal network.
+	AllowPrivate bool
+
+	// UserAgent is sent with every request.
+	UserAgent string
+
+	// MaxBytes limits the size of an image. Default: 10 MiB
+	MaxBytes int64
+
+	// MaxPixels limits width*height, so that a small file can't
+	// decode into a huge image. Default: 40 million
+	MaxPixels int
+
+	// Widths are the allowed widths of the thumbnails. Other
+	// widths are rounded up to the next one. Default: 160, 320, 640
+	Widths []int
+
+	// Quality of the jpeg thumbnails. Default: 85
+	Quality int
+
+	// RetryBroken is the time a broken url is not fetched again.
+	// Default: 7 days
+	RetryBroken time.Duration
+
+	// MaxCacheSize limits the bytes of the originals and the
+	// thumbnails. The least recently used files are removed when
+	// it is reached. Default: 1 GiB
+	MaxCacheSize int64
+
+	// MaxDecodes limits the images that are decoded at the same
+	// time, a decoded image can take up to 4*MaxPixels bytes.
+	// Default: the number of CPUs
+	MaxDecodes int
+
+	// Key signs the urls of the handler (see URL). Keep it secret.
+	Key []byte
+
+	// Hosts are the image hosts (including their subdomains) that
+	// the handler serves without a signature.
+	Hosts []string
+
+	// OnError gets called for the errors that the handler doesn't
+	// send to the client.
+	OnError func(url string, err error)
+
+	dir     string
+	now     func() time.Time
+	once    sync.Once
+	mu      sync.Mutex
+	index   map[string]Image
+	bad     map[string]Broken
+	size    int64 // of the cache, guarded by mu
+	pruneMu sync.Mutex
+	decodes chan struct{}
+}
+
+// NewFetcher uses the directory for the images and loads the urls
+// that were already fetched.
+func NewFetcher(dir string) (*Fetcher, error) {
+	for _, sub := range []string{"originals", "thumbnails"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &Fetcher{
+		dir:   dir,
+		now:   time.Now,
+		index: make(map[string]Image),
+		bad:   make(map[string]Broken),
+	}
+	indexLines, err := readLines(filepath.Join(dir, "index.jsonl"), func(data []byte) error {
+		var img Image
+		if err := json.Unmarshal(data, &img); err != nil {
+			return err
+		}
+		f.index[img.URL] = img
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	badLines, err := readLines(filepath.Join(dir, "broken.jsonl"), func(data []byte) error {
+		var b Broken
+		if err := json.Unmarshal(data, &b); err != nil {
+			return err
+		}
+		f.bad[b.URL] = b
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the files are only appended to, a url that was fetched
+	// again (or a partial line) is a line too many
+	if indexLines > len(f.index) || badLines > len(f.bad) {
+		if err := f.compact(); err != nil {
+			return nil, err
+		}
+	}
+	files, err := f.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		f.size += file.Size()
+	}
+	return f, nil
+}
+
+func (f *Fetcher) init() {
+	f.once.Do(func() {
+		if f.Client == nil {
+			f.Client = &http.Client{
+				Timeout:   15 * time.Second,
+				Transport: &http.Transport{DialContext: dialer(f.AllowPrivate)},
+			}
+		}
+		if f.MaxBytes <= 0 {
+			f.MaxBytes = 10 << 20
+		}
+		if f.MaxPixels <= 0 {
+			f.MaxPixels = 40e6
+		}
+		if len(f.Widths) == 0 {
+			f.Widths = []int{160, 320, 640}
+		}
+		if f.Quality <= 0 {
+			f.Quality = 85
+		}
+		if f.RetryBroken <= 0 {
+			f.RetryBroken = 7 * 24 * time.Hour
+		}
+		if f.MaxCacheSize <= 0 {
+			f.MaxCacheSize = 1 << 30
+		}
+		if f.MaxDecodes <= 0 {
+			f.MaxDecodes = runtime.NumCPU()
+		}
+		f.decodes = make(chan struct{}, f.MaxDecodes)
+	})
+}
+
+// Article fetches the URLToImage of the article.
+func (f *Fetcher) Article(ctx context.Context, a news.Article) (Image, error) {
+	if a.URLToImage == "" {
+		return Image{}, ErrNoImage
+	}
+	return f.Fetch(ctx, a.URLToImage)
+}
+
+// Fetch downloads and validates the image, or returns it from the
+// directory if it was fetched before. The error wraps ErrBroken if
+// the image is broken (now or in an earlier call); other errors like
+// timeouts or server errors are not recorded.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Image, error) {
+	f.init()
+
+	f.mu.Lock()
+	img, ok := f.index[rawURL]
+	b, broken := f.bad[rawURL]
+	f.mu.Unlock()
+	if ok {
+		// the original can be removed from the cache
+		if _, err := os.Stat(f.original(img.Hash)); err == nil {
+			return img, nil
+		}
+	}
+	if broken && f.now().Sub(b.Time) < f.RetryBroken {
+		return Image{}, fmt.Errorf("%w: %s", ErrBroken, b.Error)
+	}
+
+	data, err := f.download(ctx, rawURL)
+	if err == nil {
+		img, err = f.validate(ctx, rawURL, data)
+	}
+	if err != nil {
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			if recordErr := f.recordBroken(rawURL, perm.err); recordErr != nil {
+				return Image{}, recordErr
+			}
+			return Image{}, fmt.Errorf("%w: %v", ErrBroken, perm.err)
+		}
+		return Image{}, err
+	}
+
+	// the content address makes the write idempotent
+	path := f.original(img.Hash)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := writeFile(path, data); err != nil {
+			return Image{}, err
+		}
+		f.added(int64(len(data)))
+	}
+	if err := f.record(img); err != nil {
+		return Image{}, err
+	}
+	return img, nil
+}
+
+// Broken returns the recorded broken urls.
+func (f *Fetcher) Broken() []Broken {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]Broken, 0, len(f.bad))
+	for _, b := range f.bad {
+		result = append(result, b)
+	}
+	return result
+}
+
+// permanentError is an error that won't go away with a retry, for
+// example a 404 or an invalid image. It is recorded as broken.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func permanent(format string, args ...interface{}) error {
+	return &permanentError{err: fmt.Errorf(format, args...)}
+}
+
+func (f *Fetcher) download(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, permanent("invalid url %q", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/jpeg,image/png,image/gif")
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	client := f.Client
+	if ctx.Value(hostsOnlyKey{}) != nil {
+		client = f.hostsOnly(client)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrPrivateURL) {
+			return nil, permanent("%s is a private address", u.Host)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+		return nil, permanent("status code %d", resp.StatusCode)
+	default:
+		return nil, fmt.Errorf("images: status code %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "application/octet-stream") {
+		return nil, permanent("content type %q is not an image", contentType)
+	}
+	if resp.ContentLength > f.MaxBytes {
+		return nil, permanent("the image has more than %d bytes", f.MaxBytes)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, f.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > f.MaxBytes {
+		return nil, permanent("the image has more than %d bytes", f.MaxBytes)
+	}
+	return data, nil
+}
+
+// validate decodes the image. The size is checked before, so that
+// a decompression bomb is never decoded.
+func (f *Fetcher) validate(ctx context.Context, rawURL string, data []byte) (Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, permanent("decoding: %v", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > f.MaxPixels {
+		return Image{}, permanent("the image has %dx%d pixels", cfg.Width, cfg.Height)
+	}
+	if _, err := f.decode(ctx, data); err != nil {
+		if ctx.Err() != nil {
+			return Image{}, err
+		}
+		return Image{}, permanent("decoding: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return Image{
+		URL:    rawURL,
+		Hash:   hex.EncodeToString(sum[:]),
+		Format: format,
+		Width:  cfg.Width,
+		Height: cfg.Height,
+	}, nil
+}
+
+// hostsOnlyKey marks the context of a request that the handler
+// allowed because of the Hosts and not because of a signature.
+type hostsOnlyKey struct{}
+
+// hostsOnly returns a copy of the client that only follows redirects
+// to the Hosts, otherwise an open redirect on one of them would make
+// the handler an open proxy.
+func (f *Fetcher) hostsOnly(c *http.Client) *http.Client {
+	limited := *c
+	limited.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !f.allowedHost(req.URL) {
+			return ErrRedirect
+		}
+		if c.CheckRedirect != nil {
+			return c.CheckRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &limited
+}
+
+func (f *Fetcher) record(img Image) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.index[img.URL] = img
+	delete(f.bad, img.URL)
+	return appendLine(filepath.Join(f.dir, "index.jsonl"), img)
+}
+
+func (f *Fetcher) recordBroken(rawURL string, err error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b := Broken{URL: rawURL, Error: err.Error(), Time: f.now()}
+	f.bad[rawURL] = b
+	return appendLine(filepath.Join(f.dir, "broken.jsonl"), b)
+}
+
+// compact rewrites index.jsonl and broken.jsonl with one line per
+// url.
+func (f *Fetcher) compact() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var index, bad bytes.Buffer
+	enc := json.NewEncoder(&index)
+	for _, img := range f.index {
+		if err := enc.Encode(img); err != nil {
+			return err
+		}
+	}
+	enc = json.NewEncoder(&bad)
+	for _, b := range f.bad {
+		if err := enc.Encode(b); err != nil {
+			return err
+		}
+	}
+
+	if err := writeFile(filepath.Join(f.dir, "index.jsonl"), index.Bytes()); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(f.dir, "broken.jsonl"), bad.Bytes())
+}
+
+func (f *Fetcher) original(hash string) string {
+	return filepath.Join(f.dir, "originals", hash)
+}
+
+// dialer refuses private addresses after the name was resolved, so
+// that a dns name can't point to them either.
+func dialer(allowPrivate bool) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return ErrPrivateURL
+			}
+			return nil
+		},
+	}
+	return d.DialContext
+}
+
+// readLines calls fn for every line and returns the number of lines.
+// A last line without a newline is from a write that didn't finish,
+// it is skipped if fn fails.
+func readLines(path string, fn func([]byte) error) (int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var lines int
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return lines, err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			lines++
+			if fnErr := fn(line); fnErr != nil && err != io.EOF {
+				return lines, fmt.Errorf("%s: %v", path, fnErr)
+			}
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+	}
+}
+
+func appendLine(path string, v interface{}) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(file).Encode(v)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeFile writes to a temporary file first, so that a crash
+// doesn't leave half a file under the final name.
+func writeFile(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
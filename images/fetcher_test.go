@@ -0,0 +1,221 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// publisher serves images and counts the requests.
+type publisher struct {
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func (p *publisher) server(t *testing.T) *httptest.Server {
+	photo := testPNG(t, 200, 100)
+	p.requests = make(map[string]int)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.requests[r.URL.Path]++
+		p.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/photo.png", "/copy.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(photo)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		case "/truncated.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(photo[:len(photo)/2])
+		case "/redirect.png":
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+		case "/down.png":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testFetcher(t *testing.T) *Fetcher {
+	f, err := NewFetcher(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.AllowPrivate = true // httptest listens on localhost
+	return f
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	t.Parallel()
+
+	p := &publisher{}
+	srv := p.server(t)
+	f := testFetcher(t)
+
+	img, err := f.Article(context.Background(), news.Article{URLToImage: srv.URL + "/photo.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format != "png" || img.Width != 200 || img.Height != 100 || len(img.Hash) != 64 {
+		t.Fatalf("unexpected image %+v", img)
+	}
+
+	// the same content under another url has the same address
+	other, _ := f.Fetch(context.Background(), srv.URL+"/copy.png")
+	if other.Hash != img.Hash {
+		t.Fatal("expected the same hash for the same content")
+	}
+
+	// a new fetcher knows the fetched urls
+	f2, err := NewFetcher(f.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f2.AllowPrivate = true
+	if _, err := f2.Fetch(context.Background(), srv.URL+"/photo.png"); err != nil {
+		t.Fatal(err)
+	}
+	if p.requests["/photo.png"] != 1 {
+		t.Fatal("expected a single download but got ", p.requests["/photo.png"])
+	}
+
+	if _, err := f.Article(context.Background(), news.Article{}); err != ErrNoImage {
+		t.Fatal("expected ErrNoImage but got ", err)
+	}
+}
+
+func TestFetcher_Broken(t *testing.T) {
+	t.Parallel()
+
+	p := &publisher{}
+	srv := p.server(t)
+	f := testFetcher(t)
+	f.MaxBytes = 1 << 20
+
+	for _, path := range []string{"/missing.png", "/page.html", "/truncated.png"} {
+		for i := 0; i < 2; i++ {
+			if _, err := f.Fetch(context.Background(), srv.URL+path); !errors.Is(err, ErrBroken) {
+				t.Fatalf("%s: expected ErrBroken but got %v", path, err)
+			}
+		}
+		if p.requests[path] != 1 {
+			t.Fatalf("%s: expected the broken image to be fetched once but got %d", path, p.requests[path])
+		}
+	}
+	if len(f.Broken()) != 3 {
+		t.Fatal("expected 3 broken images but got ", f.Broken())
+	}
+
+	// a server error can go away and is not recorded
+	for i := 0; i < 2; i++ {
+		if _, err := f.Fetch(context.Background(), srv.URL+"/down.png"); err == nil || errors.Is(err, ErrBroken) {
+			t.Fatal("expected a temporary error but got ", err)
+		}
+	}
+	if p.requests["/down.png"] != 2 {
+		t.Fatal("expected the server error to be retried")
+	}
+
+	// the record expires and is persisted
+	f2, _ := NewFetcher(f.dir)
+	f2.AllowPrivate = true
+	f2.now = func() time.Time { return time.Now().Add(8 * 24 * time.Hour) }
+	f2.Fetch(context.Background(), srv.URL+"/missing.png")
+	if len(f2.Broken()) != 3 || p.requests["/missing.png"] != 2 {
+		t.Fatal("expected the expired record to be fetched again")
+	}
+}
+
+func TestFetcher_Index(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	index := filepath.Join(dir, "index.jsonl")
+	line := `{"url":"http://example.com/a.png","hash":"abc","format":"png","width":1,"height":1}` + "\n"
+
+	// a url that was fetched twice and a write that didn't finish
+	err := ioutil.WriteFile(index, []byte(line+line+`{"url":"http://exa`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFetcher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.index) != 1 {
+		t.Fatal("expected 1 image but got ", len(f.index))
+	}
+	data, _ := ioutil.ReadFile(index)
+	if string(data) != line {
+		t.Fatalf("expected the index to be compacted but got %q", data)
+	}
+
+	// only the last line can be partial
+	ioutil.WriteFile(index, []byte(`{"url":"http://exa`+"\n"+line), 0644)
+	if _, err := NewFetcher(dir); err == nil {
+		t.Fatal("expected an error for a broken line")
+	}
+}
+
+func TestFetcher_Limits(t *testing.T) {
+	t.Parallel()
+
+	srv := (&publisher{}).server(t)
+
+	f := testFetcher(t)
+	f.MaxBytes = 100
+	if _, err := f.Fetch(context.Background(), srv.URL+"/photo.png"); !errors.Is(err, ErrBroken) {
+		t.Fatal("expected the image to be too large but got ", err)
+	}
+
+	f = testFetcher(t)
+	f.MaxPixels = 10000
+	if _, err := f.Fetch(context.Background(), srv.URL+"/photo.png"); !errors.Is(err, ErrBroken) {
+		t.Fatal("expected too many pixels but got ", err)
+	}
+}
+
+func TestFetcher_PrivateAddress(t *testing.T) {
+	t.Parallel()
+
+	srv := (&publisher{}).server(t)
+	f, _ := NewFetcher(t.TempDir())
+
+	_, err := f.Fetch(context.Background(), srv.URL+"/photo.png")
+	if !errors.Is(err, ErrBroken) {
+		t.Fatal("expected localhost to be refused but got ", err)
+	}
+}
@@ -0,0 +1,145 @@
+package images
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Handler serves thumbnails for the query parameters "url" (the
+// image), "w" (the width, optional) and "sig" (the signature of URL).
+// Broken images are a 404, so that the front-end can show a
+// placeholder.
+//
+// Only signed urls (see Key) and images of the Hosts are served,
+// everything else is a 403. Redirects of unsigned urls are only
+// followed to the Hosts. Without a Key and Hosts every request is
+// refused, so that the handler is not an open proxy.
+//
+//	<img src="/images?url=https%3A%2F%2Fexample.com%2Fa.jpg&w=320&sig=...">
+func (f *Fetcher) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		rawURL := query.Get("url")
+		if rawURL == "" {
+			http.Error(w, "the url parameter is missing", http.StatusBadRequest)
+			return
+		}
+		width := 0
+		if v := query.Get("w"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid width", http.StatusBadRequest)
+				return
+			}
+			width = n
+		}
+		signed := f.signed(rawURL, query.Get("w"), query.Get("sig"))
+		u, err := url.Parse(rawURL)
+		if !signed && (err != nil || !f.allowedHost(u)) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		ctx := r.Context()
+		if !signed {
+			ctx = context.WithValue(ctx, hostsOnlyKey{}, true)
+		}
+
+		// the errors stay in the logs, they can contain internal
+		// addresses and paths
+		path, err := f.Thumbnail(ctx, rawURL, width)
+		switch {
+		case errors.Is(err, ErrBroken):
+			http.Error(w, "image not found", http.StatusNotFound)
+			return
+		case errors.Is(err, ErrRedirect):
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		case err != nil:
+			f.onError(rawURL, err)
+			http.Error(w, "the image could not be fetched", http.StatusBadGateway)
+			return
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			f.onError(rawURL, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			f.onError(rawURL, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		// the file name is the content address, so it never changes
+		w.Header().Set("ETag", `"`+strings.TrimSuffix(filepath.Base(path), ".jpg")+`"`)
+		w.Header().Set("Cache-Control", "public, max-age=604800, immutable")
+		w.Header().Set("Content-Type", "image/jpeg")
+		http.ServeContent(w, r, "", info.ModTime(), file)
+	})
+}
+
+// URL returns the url of the thumbnail for the handler at base, for
+// example f.URL("/images", article.URLToImage, 320). The url is
+// signed if Key is set.
+func (f *Fetcher) URL(base, imageURL string, width int) string {
+	v := url.Values{}
+	v.Set("url", imageURL)
+	if width > 0 {
+		v.Set("w", strconv.Itoa(width))
+	}
+	if len(f.Key) > 0 {
+		v.Set("sig", f.sign(imageURL, v.Get("w")))
+	}
+	return base + "?" + v.Encode()
+}
+
+// sign returns the hmac of the parameters. The width is part of it,
+// otherwise a signed url could create thumbnails of every width.
+func (f *Fetcher) sign(imageURL, width string) string {
+	mac := hmac.New(sha256.New, f.Key)
+	mac.Write([]byte(imageURL + "\n" + width))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signed reports whether the signature of the parameters is valid.
+func (f *Fetcher) signed(imageURL, width, sig string) bool {
+	return len(f.Key) > 0 && sig != "" && hmac.Equal([]byte(sig), []byte(f.sign(imageURL, width)))
+}
+
+// allowedHost reports whether the host of the url is one of the
+// Hosts. The handler serves these images without a signature, also
+// after a redirect.
+func (f *Fetcher) allowedHost(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range f.Hosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fetcher) onError(rawURL string, err error) {
+	if f.OnError != nil {
+		f.OnError(rawURL, err)
+	}
+}
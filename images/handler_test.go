@@ -0,0 +1,113 @@
+package images
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFetcher_Handler(t *testing.T) {
+	t.Parallel()
+
+	publisher := (&publisher{}).server(t)
+	f := testFetcher(t)
+	f.Key = []byte("secret")
+	h := f.Handler()
+
+	get := func(url string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get(f.URL("/images", publisher.URL+"/photo.png", 320), nil)
+	if rec.Code != 200 || rec.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("unexpected response %d %v", rec.Code, rec.Header())
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" || rec.Header().Get("Cache-Control") == "" {
+		t.Fatal("expected caching headers")
+	}
+
+	rec = get(f.URL("/images", publisher.URL+"/photo.png", 320), http.Header{"If-None-Match": {etag}})
+	if rec.Code != http.StatusNotModified {
+		t.Fatal("expected 304 but got ", rec.Code)
+	}
+
+	if rec := get(f.URL("/images", publisher.URL+"/missing.png", 0), nil); rec.Code != http.StatusNotFound {
+		t.Fatal("expected 404 for a broken image but got ", rec.Code)
+	}
+	rec = get(f.URL("/images", publisher.URL+"/down.png", 0), nil)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatal("expected 502 for a server error but got ", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "status code") {
+		t.Fatal("expected the error to stay on the server but got ", rec.Body.String())
+	}
+	if rec := get("/images?url=x&w=big", nil); rec.Code != http.StatusBadRequest {
+		t.Fatal("expected 400 for an invalid width but got ", rec.Code)
+	}
+}
+
+func TestFetcher_HandlerForbidden(t *testing.T) {
+	t.Parallel()
+
+	publisher := (&publisher{}).server(t)
+	f := testFetcher(t)
+	h := f.Handler()
+
+	get := func(target string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec.Code
+	}
+
+	// without a key and hosts nothing is served
+	image := publisher.URL + "/photo.png"
+	if code := get(f.URL("/images", image, 320)); code != http.StatusForbidden {
+		t.Fatal("expected 403 but got ", code)
+	}
+
+	f.Key = []byte("secret")
+	signed := f.URL("/images", image, 320)
+	if code := get(signed); code != http.StatusOK {
+		t.Fatal("expected the signed url to be served but got ", code)
+	}
+	if code := get(strings.Replace(signed, "w=320", "w=640", 1)); code != http.StatusForbidden {
+		t.Fatal("expected a changed width to be refused but got ", code)
+	}
+	other := &Fetcher{Key: []byte("other")}
+	if code := get(other.URL("/images", image, 320)); code != http.StatusForbidden {
+		t.Fatal("expected another key to be refused but got ", code)
+	}
+
+	// the hosts don't need a signature
+	f.Hosts = []string{"127.0.0.1"}
+	if code := get("/images?url=" + image); code != http.StatusOK {
+		t.Fatal("expected the allowed host to be served but got ", code)
+	}
+	if code := get("/images?url=http://example.com/a.png"); code != http.StatusForbidden {
+		t.Fatal("expected another host to be refused but got ", code)
+	}
+
+	// a redirect of an unsigned url has to stay on the hosts
+	redirect := func(to string) string {
+		return publisher.URL + "/redirect.png?to=" + url.QueryEscape(to)
+	}
+	if code := get("/images?url=" + url.QueryEscape(redirect(image))); code != http.StatusOK {
+		t.Fatal("expected a redirect to the allowed host to be served but got ", code)
+	}
+	localhost := strings.Replace(publisher.URL, "127.0.0.1", "localhost", 1) + "/copy.png"
+	if code := get("/images?url=" + url.QueryEscape(redirect(localhost))); code != http.StatusForbidden {
+		t.Fatal("expected a redirect to another host to be refused but got ", code)
+	}
+	if code := get(f.URL("/images", redirect(localhost), 320)); code != http.StatusOK {
+		t.Fatal("expected the redirect of a signed url to be served but got ", code)
+	}
+}
@@ -0,0 +1,178 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Thumbnail returns the path of a jpeg thumbnail of the image with the
+// width (rounded up to one of Widths). It fetches the image if needed
+// and creates the thumbnail on the first use. Images are never
+// scaled up.
+func (f *Fetcher) Thumbnail(ctx context.Context, rawURL string, width int) (string, error) {
+	img, err := f.Fetch(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	width = f.width(width)
+	if width > img.Width {
+		width = img.Width
+	}
+	path := filepath.Join(f.dir, "thumbnails", img.Hash+"-"+strconv.Itoa(width)+".jpg")
+	if _, err := os.Stat(path); err == nil {
+		f.touch(path)
+		return path, nil
+	}
+
+	// two concurrent calls create the same file, the rename
+	// makes sure that nobody sees half of it
+	data, err := ioutil.ReadFile(f.original(img.Hash))
+	if os.IsNotExist(err) {
+		// removed from the cache in the meantime
+		if img, err = f.Fetch(ctx, rawURL); err == nil {
+			data, err = ioutil.ReadFile(f.original(img.Hash))
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	src, err := f.decode(ctx, data)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resize(src, width), &jpeg.Options{Quality: f.Quality}); err != nil {
+		return "", err
+	}
+	if err := writeFile(path, buf.Bytes()); err != nil {
+		return "", err
+	}
+	f.added(int64(buf.Len()))
+	return path, nil
+}
+
+// decode decodes the image, but only MaxDecodes at the same time.
+func (f *Fetcher) decode(ctx context.Context, data []byte) (image.Image, error) {
+	f.init()
+
+	select {
+	case f.decodes <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-f.decodes }()
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// width rounds up to the next allowed width, so that the number of
+// thumbnails per image is limited.
+func (f *Fetcher) width(w int) int {
+	f.init()
+
+	largest := 0
+	best := 0
+	for _, allowed := range f.Widths {
+		if allowed > largest {
+			largest = allowed
+		}
+		if allowed >= w && (best == 0 || allowed < best) {
+			best = allowed
+		}
+	}
+	if best == 0 {
+		return largest
+	}
+	return best
+}
+
+// resize scales the image down to the width by averaging the source
+// pixels of every target pixel (area averaging). Transparent parts
+// become white, because jpeg has no alpha. The source is read row by
+// row, so that only the small target image is allocated.
+func resize(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if width <= 0 || width > sw {
+		width = sw
+	}
+	height := (sh*width + sw/2) / sw
+	if height < 1 {
+		height = 1
+	}
+
+	// the target column of every source column and the number of
+	// source columns of every target column
+	cols := make([]int, sw)
+	n := make([]int, width)
+	for x := 0; x < width; x++ {
+		for sx := x * sw / width; sx < (x+1)*sw/width; sx++ {
+			cols[sx] = x
+			n[x]++
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	row := make([]uint8, 3*sw)
+	sum := make([]int, 3*width)
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		for i := range sum {
+			sum[i] = 0
+		}
+		for sy := y0; sy < y1; sy++ {
+			flatRow(src, b.Min.Y+sy, row)
+			for sx, x := range cols {
+				sum[3*x] += int(row[3*sx])
+				sum[3*x+1] += int(row[3*sx+1])
+				sum[3*x+2] += int(row[3*sx+2])
+			}
+		}
+
+		for x := 0; x < width; x++ {
+			count := n[x] * (y1 - y0)
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(sum[3*x] / count)
+			dst.Pix[j+1] = uint8(sum[3*x+1] / count)
+			dst.Pix[j+2] = uint8(sum[3*x+2] / count)
+			dst.Pix[j+3] = 255
+		}
+	}
+	return dst
+}
+
+// flatRow writes the rgb values of the row y onto a white background
+// into row. Jpegs decode into a YCbCr, which is converted without the
+// color interface.
+func flatRow(src image.Image, y int, row []uint8) {
+	b := src.Bounds()
+	if img, ok := src.(*image.YCbCr); ok {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			yi, ci := img.YOffset(x, y), img.COffset(x, y)
+			i := 3 * (x - b.Min.X)
+			row[i], row[i+1], row[i+2] = color.YCbCrToRGB(img.Y[yi], img.Cb[ci], img.Cr[ci])
+		}
+		return
+	}
+
+	for x := b.Min.X; x < b.Max.X; x++ {
+		// the values are premultiplied by alpha, so the white
+		// shows through by the missing alpha
+		r, g, bl, a := src.At(x, y).RGBA()
+		white := 0xffff - a
+		i := 3 * (x - b.Min.X)
+		row[i] = uint8((r + white) >> 8)
+		row[i+1] = uint8((g + white) >> 8)
+		row[i+2] = uint8((bl + white) >> 8)
+	}
+}
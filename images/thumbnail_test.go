@@ -0,0 +1,143 @@
+package images
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResize(t *testing.T) {
+	t.Parallel()
+
+	// left half black, right half transparent
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			src.Set(x, y, color.Black)
+		}
+	}
+
+	dst := resize(src, 10)
+	if dst.Bounds().Dx() != 10 || dst.Bounds().Dy() != 5 {
+		t.Fatal("unexpected size ", dst.Bounds())
+	}
+	if r, _, _, _ := dst.At(0, 0).RGBA(); r != 0 {
+		t.Fatal("expected black on the left")
+	}
+	if r, _, _, _ := dst.At(9, 4).RGBA(); r != 0xffff {
+		t.Fatal("expected the transparent part to be white")
+	}
+
+	if resize(src, 100).Bounds().Dx() != 40 {
+		t.Fatal("expected no upscaling")
+	}
+
+	// jpegs decode into YCbCr
+	ycbcr := image.NewYCbCr(image.Rect(0, 0, 40, 20), image.YCbCrSubsampleRatio420)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = 255
+	}
+	for i := range ycbcr.Cb {
+		ycbcr.Cb[i], ycbcr.Cr[i] = 128, 128
+	}
+	if r, g, b, _ := resize(ycbcr, 10).At(5, 2).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Fatal("expected white but got ", r, g, b)
+	}
+}
+
+func TestFetcher_Prune(t *testing.T) {
+	t.Parallel()
+
+	p := &publisher{}
+	srv := p.server(t)
+	f := testFetcher(t)
+
+	small, _ := f.Thumbnail(context.Background(), srv.URL+"/photo.png", 160)
+	large, err := f.Thumbnail(context.Background(), srv.URL+"/photo.png", 320)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := f.original(strings.Split(filepath.Base(large), "-")[0])
+
+	// the original is the least recently used file, the large
+	// thumbnail the most recently used one
+	for i, path := range []string{original, small, large} {
+		used := time.Now().Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(path, used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, _ := os.Stat(large)
+	f.MaxCacheSize = info.Size()*10/9 + 10
+	if err := f.prune(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{original, small} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed but got %v", path, err)
+		}
+	}
+	if _, err := os.Stat(large); err != nil {
+		t.Fatal("expected the large thumbnail to stay but got ", err)
+	}
+
+	// the original is fetched again for a new thumbnail
+	if _, err := f.Thumbnail(context.Background(), srv.URL+"/photo.png", 160); err != nil {
+		t.Fatal(err)
+	}
+	if p.requests["/photo.png"] != 2 {
+		t.Fatal("expected the removed original to be fetched again but got ", p.requests["/photo.png"])
+	}
+}
+
+func TestFetcher_MaxDecodes(t *testing.T) {
+	t.Parallel()
+
+	f := testFetcher(t)
+	f.MaxDecodes = 1
+	f.init()
+	f.decodes <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.decode(ctx, nil); err != context.Canceled {
+		t.Fatal("expected to wait for the running decode but got ", err)
+	}
+}
+
+func TestFetcher_Thumbnail(t *testing.T) {
+	t.Parallel()
+
+	srv := (&publisher{}).server(t)
+	f := testFetcher(t)
+
+	path, err := f.Thumbnail(context.Background(), srv.URL+"/photo.png", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "-160.jpg") {
+		t.Fatal("expected the width to be rounded up to 160 but got ", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	cfg, err := jpeg.DecodeConfig(file)
+	if err != nil || cfg.Width != 160 || cfg.Height != 80 {
+		t.Fatalf("unexpected thumbnail %+v %v", cfg, err)
+	}
+
+	// the image is only 200 pixels wide
+	path, _ = f.Thumbnail(context.Background(), srv.URL+"/photo.png", 1000)
+	if !strings.HasSuffix(path, "-200.jpg") {
+		t.Fatal("expected the original width but got ", path)
+	}
+}
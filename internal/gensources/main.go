@@ -0,0 +1,161 @@
+// Command gensources writes the snapshot of the sources catalogue
+// that is compiled into the package. It is run by go generate:
+//
+//	go generate github.com/JohannesKaufmann/News-API-go
+//
+// Without flags it only reads the -fixture file (a response of the
+// sources endpoint or a plain json array), so go generate never
+// uses the network. With -key the sources are loaded from the api
+// and the response is saved to the -fixture file, so that the next
+// go generate reproduces the snapshot:
+//
+//	go run ./internal/gensources -key <api key> -fixture testdata/sources.json
+//
+// It does not import the news package, so it still runs if the
+// snapshot it replaces is missing or broken.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// source has the fields of news.Source.
+type source struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	Category    string `json:"category"`
+	Language    string `json:"language"`
+	Country     string `json:"country"`
+}
+
+// response is the body of the sources endpoint.
+type response struct {
+	Status  string   `json:"status"`
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Sources []source `json:"sources"`
+}
+
+const sourcesURL = "https://newsapi.org/v2/sources"
+
+func main() {
+	key := flag.String("key", "", "load the sources from the api with the key")
+	fixture := flag.String("fixture", "", "the json file used without a key, or where the response is saved with one")
+	out := flag.String("o", "sources_snapshot.go", "the file to write")
+	flag.Parse()
+
+	sources, origin, err := load(*key, *fixture)
+	if err != nil {
+		log.Fatal("error: ", err)
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].ID < sources[j].ID
+	})
+
+	src, err := render(sources, origin)
+	if err != nil {
+		log.Fatal("error: ", err)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal("error: ", err)
+	}
+	log.Printf("wrote %d sources from %s to %s", len(sources), origin, *out)
+}
+
+func load(key, fixture string) ([]source, string, error) {
+	if key != "" {
+		sources, err := fetch(key)
+		if err != nil {
+			return nil, "", err
+		}
+		if fixture == "" {
+			return sources, "the api", nil
+		}
+		if err := save(fixture, sources); err != nil {
+			return nil, "", err
+		}
+		return sources, fixture, nil
+	}
+	if fixture == "" {
+		return nil, "", fmt.Errorf("either -key or -fixture is needed")
+	}
+
+	data, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		return nil, "", err
+	}
+	data = bytes.TrimSpace(data)
+
+	var sources []source
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &sources)
+	} else {
+		var res response
+		err = json.Unmarshal(data, &res)
+		sources = res.Sources
+	}
+	return sources, fixture, err
+}
+
+// fetch loads every source from the api.
+func fetch(key string) ([]source, error) {
+	req, err := http.NewRequest("GET", sourcesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Api-Key", key)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var res response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("status code %d: %v", resp.StatusCode, err)
+	}
+	if res.Status != "ok" {
+		return nil, fmt.Errorf("%s: %s", res.Code, res.Message)
+	}
+	return res.Sources, nil
+}
+
+// save writes the sources as a response of the sources endpoint.
+func save(path string, sources []source) error {
+	data, err := json.MarshalIndent(response{Status: "ok", Sources: sources}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func render(sources []source, origin string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gensources from " + origin + "; DO NOT EDIT.\n\n")
+	buf.WriteString("package news\n\n")
+	buf.WriteString("var sourcesSnapshot = []Source{\n")
+	for _, s := range sources {
+		fmt.Fprintf(&buf, "\t{ID: %s, Name: %s, Description: %s, URL: %s, Category: %s, Language: %s, Country: %s},\n",
+			strconv.Quote(s.ID), strconv.Quote(s.Name), strconv.Quote(s.Description),
+			strconv.Quote(s.URL), strconv.Quote(s.Category), strconv.Quote(s.Language), strconv.Quote(s.Country),
+		)
+	}
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
+}
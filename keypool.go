@@ -0,0 +1,218 @@
+package news
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// KeyStrategy decides which key of a KeyPool is used next.
+type KeyStrategy int
+
+const (
+	// RoundRobin uses the healthy keys one after the other.
+	RoundRobin KeyStrategy = iota
+
+	// LeastUsed uses the healthy key with the fewest requests.
+	LeastUsed
+
+	// PrimaryFallback uses the first healthy key, the others
+	// only if the ones before them are quarantined.
+	PrimaryFallback
+)
+
+// ErrNoHealthyKey is returned if every key of the pool is
+// quarantined.
+var ErrNoHealthyKey = errors.New("every key of the pool is quarantined")
+
+// APIKeys is the key pool used for every request that has no
+// APIKey. Default: nil (no pool)
+var APIKeys *KeyPool
+
+// KeyPool spreads the requests over multiple api keys. A key that
+// is exhausted, disabled, invalid or rate limited is quarantined for
+// a while and the request is sent again with the next key.
+//
+// The keys are never part of an error or the stats.
+type KeyPool struct {
+	// Quarantine is how long a key that is exhausted, disabled
+	// or invalid is not used. Default: 1 hour
+	Quarantine time.Duration
+
+	// RateLimitQuarantine is how long a rate limited key is
+	// not used. Default: 1 minute
+	RateLimitQuarantine time.Duration
+
+	strategy KeyStrategy
+
+	mu   sync.Mutex
+	keys []*poolKey
+	next int
+
+	// now can be replaced in tests.
+	now func() time.Time
+}
+
+type poolKey struct {
+	key string
+	KeyStats
+}
+
+// KeyStats are the usage and health of one key of the pool.
+type KeyStats struct {
+	// ID identifies the key without revealing it. It is the
+	// start of the sha256 hash of the key.
+	ID string
+
+	Requests int
+	Failures int
+
+	// LastError is the code of the last error, for example
+	// "apiKeyExhausted".
+	LastError string
+
+	// QuarantinedUntil is zero if the key was never quarantined.
+	QuarantinedUntil time.Time
+}
+
+// Healthy reports if the key can be used at the time.
+func (s KeyStats) Healthy(at time.Time) bool {
+	return !at.Before(s.QuarantinedUntil)
+}
+
+// NewKeyPool creates a pool with the keys. Empty and
+// duplicate keys are ignored.
+func NewKeyPool(keys []string, strategy KeyStrategy) *KeyPool {
+	p := &KeyPool{
+		Quarantine:          time.Hour,
+		RateLimitQuarantine: time.Minute,
+		strategy:            strategy,
+		now:                 time.Now,
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		sum := sha256.Sum256([]byte(key))
+		p.keys = append(p.keys, &poolKey{
+			key:      key,
+			KeyStats: KeyStats{ID: hex.EncodeToString(sum[:4])},
+		})
+	}
+	return p
+}
+
+// Stats returns the usage and health of every key in the
+// order they were passed to NewKeyPool.
+func (p *KeyPool) Stats() []KeyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]KeyStats, len(p.keys))
+	for i, k := range p.keys {
+		stats[i] = k.KeyStats
+	}
+	return stats
+}
+
+// pick returns the key for the next request.
+func (p *KeyPool) pick() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var best *poolKey
+
+	switch p.strategy {
+	case LeastUsed:
+		for _, k := range p.keys {
+			if k.Healthy(now) && (best == nil || k.Requests < best.Requests) {
+				best = k
+			}
+		}
+	case PrimaryFallback:
+		for _, k := range p.keys {
+			if k.Healthy(now) {
+				best = k
+				break
+			}
+		}
+	default:
+		for i := range p.keys {
+			k := p.keys[(p.next+i)%len(p.keys)]
+			if k.Healthy(now) {
+				best = k
+				p.next = (p.next + i + 1) % len(p.keys)
+				break
+			}
+		}
+	}
+
+	if best == nil {
+		return "", ErrNoHealthyKey
+	}
+	return best.key, nil
+}
+
+// report records the result of a request with the key. It returns
+// true if the key was quarantined because of the error.
+func (p *KeyPool) report(key string, err *Exception) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var k *poolKey
+	for _, candidate := range p.keys {
+		if candidate.key == key {
+			k = candidate
+		}
+	}
+	if k == nil {
+		return false
+	}
+
+	k.Requests++
+	if err == nil {
+		return false
+	}
+	k.Failures++
+	k.LastError = err.Code
+
+	var quarantine time.Duration
+	switch err.Code {
+	case "apiKeyExhausted", "apiKeyDisabled", "apiKeyInvalid":
+		quarantine = p.Quarantine
+	case "rateLimited":
+		quarantine = p.RateLimitQuarantine
+	default:
+		return false
+	}
+	k.QuarantinedUntil = p.now().Add(quarantine)
+	return true
+}
+
+// redactError removes the api key from the url in the errors
+// of the http client, so that it can't end up in a log.
+func redactError(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return &url.Error{Op: urlErr.Op, URL: "REDACTED", Err: urlErr.Err}
+	}
+	q := u.Query()
+	if q.Get("apiKey") != "" {
+		q.Set("apiKey", "REDACTED")
+		u.RawQuery = q.Encode()
+	}
+	return &url.Error{Op: urlErr.Op, URL: u.String(), Err: urlErr.Err}
+}
@@ -0,0 +1,164 @@
+package news
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyPool_Strategies(t *testing.T) {
+	t.Parallel()
+
+	pick := func(p *KeyPool, n int) string {
+		var keys []string
+		for i := 0; i < n; i++ {
+			key, _ := p.pick()
+			p.report(key, nil)
+			keys = append(keys, key)
+		}
+		return strings.Join(keys, ",")
+	}
+
+	if keys := pick(NewKeyPool([]string{"a", "b", "c", "a", ""}, RoundRobin), 4); keys != "a,b,c,a" {
+		t.Fatal("unexpected round robin order ", keys)
+	}
+	if keys := pick(NewKeyPool([]string{"a", "b"}, PrimaryFallback), 3); keys != "a,a,a" {
+		t.Fatal("expected only the primary key but got ", keys)
+	}
+
+	p := NewKeyPool([]string{"a", "b", "c"}, LeastUsed)
+	p.report("a", nil)
+	p.report("a", nil)
+	p.report("b", nil)
+	if keys := pick(p, 3); keys != "c,b,c" {
+		t.Fatal("expected the least used keys but got ", keys)
+	}
+}
+
+func TestKeyPool_Quarantine(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewKeyPool([]string{"a", "b"}, PrimaryFallback)
+	p.now = func() time.Time { return now }
+
+	if !p.report("a", &Exception{Code: "apiKeyExhausted"}) {
+		t.Fatal("expected the key to be quarantined")
+	}
+	if p.report("b", &Exception{Code: "[requesting json]"}) {
+		t.Fatal("did not expect a network error to quarantine the key")
+	}
+	if key, _ := p.pick(); key != "b" {
+		t.Fatal("expected the fallback key but got ", key)
+	}
+
+	p.report("b", &Exception{Code: "rateLimited"})
+	if _, err := p.pick(); err != ErrNoHealthyKey {
+		t.Fatal("expected no healthy key but got ", err)
+	}
+
+	// the rate limit is over earlier than the exhausted quota
+	now = now.Add(2 * time.Minute)
+	if key, _ := p.pick(); key != "b" {
+		t.Fatal("expected the rate limited key to be back but got ", key)
+	}
+	now = now.Add(time.Hour)
+	if key, _ := p.pick(); key != "a" {
+		t.Fatal("expected the primary key to be back but got ", key)
+	}
+
+	stats := p.Stats()
+	if stats[0].Requests != 1 || stats[0].Failures != 1 || stats[0].LastError != "apiKeyExhausted" {
+		t.Fatalf("unexpected stats %+v", stats[0])
+	}
+	for _, s := range stats {
+		if s.ID == "a" || s.ID == "b" || len(s.ID) != 8 {
+			t.Fatal("expected the id not to reveal the key but got ", s.ID)
+		}
+	}
+}
+
+func TestKeyPool_Failover(t *testing.T) {
+	t.Parallel()
+
+	var used []string
+	pool := NewKeyPool([]string{"exhausted", "working"}, RoundRobin)
+	c := mockClient(Config{Keys: pool}, func(req *http.Request) (*http.Response, error) {
+		key := req.URL.Query().Get("apiKey")
+		used = append(used, key)
+
+		status, json := http.StatusOK, `{"status":"ok","totalResults":0,"articles":[]}`
+		if key == "exhausted" {
+			status, json = http.StatusTooManyRequests, `{"status":"error","code":"apiKeyExhausted","message":"no requests left"}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
+
+	if _, _, err := c.Everything(EverythingOptions{}); err != nil {
+		t.Fatal("expected the second key to be used but got ", err)
+	}
+	if _, _, err := c.Everything(EverythingOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(used, ",") != "exhausted,working,working" {
+		t.Fatal("expected the exhausted key to be skipped but got ", used)
+	}
+
+	// a key of the options is used instead of the pool
+	used = nil
+	c.Everything(EverythingOptions{APIKey: "own"})
+	if len(used) != 1 || used[0] != "own" {
+		t.Fatal("expected the own key but got ", used)
+	}
+}
+
+func TestKeyPool_AllQuarantined(t *testing.T) {
+	t.Parallel()
+
+	c := mockClient(Config{Keys: NewKeyPool([]string{"a", "b"}, RoundRobin)}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"error","code":"apiKeyInvalid"}`)),
+		}, nil
+	})
+
+	_, _, err := c.Everything(EverythingOptions{})
+	if err == nil || err.Code != "apiKeyInvalid" {
+		t.Fatal("expected the error of the last key but got ", err)
+	}
+
+	_, _, err = c.Everything(EverythingOptions{})
+	if err == nil || err.Code != "[key pool]" {
+		t.Fatal("expected the pool to be empty but got ", err)
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	t.Parallel()
+
+	// the http.Client puts the url into the error
+	c := NewClient(Config{
+		APIKey: "secret",
+		HTTPClient: &http.Client{Transport: roundTripper(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})},
+	})
+
+	_, _, err := c.Everything(EverythingOptions{})
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Fatal("expected the key to be removed from the error but got ", err)
+	}
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
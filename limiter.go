@@ -0,0 +1,107 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExhausted is returned by the QuotaLimiter if the
+// requests for the current window are used up.
+var ErrQuotaExhausted = errors.New("the quota of the limiter is exhausted")
+
+// Limiter gets asked before every request. If Wait returns an
+// error the request is not sent.
+type Limiter interface {
+	Wait() error
+}
+
+// ContextLimiter is a Limiter that stops waiting when the context
+// of the request is canceled. WaitContext is used instead of Wait if
+// the limiter has it.
+type ContextLimiter interface {
+	Limiter
+	WaitContext(ctx context.Context) error
+}
+
+// RequestLimiter is the limiter used for every request.
+// Default: nil (no limit)
+var RequestLimiter Limiter
+
+// QuotaLimiter allows a fixed number of requests per window,
+// for example the 1000 requests per day of the developer plan.
+type QuotaLimiter struct {
+	requests int
+	window   time.Duration
+
+	mu    sync.Mutex
+	start time.Time
+	count int
+
+	// now can be replaced in tests.
+	now func() time.Time
+}
+
+// NewQuotaLimiter creates a limiter that allows the number of
+// requests per window.
+func NewQuotaLimiter(requests int, window time.Duration) *QuotaLimiter {
+	return &QuotaLimiter{
+		requests: requests,
+		window:   window,
+		now:      time.Now,
+	}
+}
+
+// Wait uses up one request of the quota. It does not block but
+// returns ErrQuotaExhausted if nothing is left.
+func (l *QuotaLimiter) Wait() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reset()
+	if l.count >= l.requests {
+		return ErrQuotaExhausted
+	}
+	l.count++
+	return nil
+}
+
+// WaitContext is like Wait but does not use up a request if the
+// context is already canceled.
+func (l *QuotaLimiter) WaitContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return l.Wait()
+}
+
+// waitLimiter waits for the limiter, with the context if the
+// limiter supports it.
+func waitLimiter(ctx context.Context, l Limiter) error {
+	if cl, ok := l.(ContextLimiter); ok {
+		return cl.WaitContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return l.Wait()
+}
+
+// Remaining returns the number of requests that are left
+// in the current window.
+func (l *QuotaLimiter) Remaining() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reset()
+	return l.requests - l.count
+}
+
+func (l *QuotaLimiter) reset() {
+	now := l.now()
+	if l.start.IsZero() || now.Sub(l.start) >= l.window {
+		l.start = now
+		l.count = 0
+	}
+}
@@ -0,0 +1,44 @@
+package news
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuotaLimiter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewQuotaLimiter(2, time.Hour)
+	l.now = func() time.Time { return now }
+
+	if l.Wait() != nil || l.Wait() != nil {
+		t.Fatal("expected the first two requests to be allowed")
+	}
+	if l.Wait() != ErrQuotaExhausted {
+		t.Fatal("expected the quota to be exhausted")
+	}
+
+	now = now.Add(time.Hour)
+	if l.Remaining() != 2 {
+		t.Fatal("expected a new window but got ", l.Remaining())
+	}
+}
+
+func TestFetch_Limiter(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(Config{
+		HTTPClient: &ClientMock{},
+		Limiter:    NewQuotaLimiter(0, time.Hour),
+	})
+
+	_, _, err := c.Everything(EverythingOptions{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "quota") {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package live pushes new articles to browsers as Server-Sent Events.
+//
+// One Watcher polls the news api and every new article is sent to all
+// connected clients, so the number of requests doesn't grow with the
+// number of open dashboards. A client that reconnects with the
+// Last-Event-ID header gets the events it missed.
+//
+//	var es = new EventSource("/headlines");
+//	es.addEventListener("article", e => show(JSON.parse(e.data)));
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// Handler is a http.Handler for Server-Sent Events. Call Run to start
+// the polling.
+type Handler struct {
+	// Watcher polls the query. Use news.NewTopHeadlinesWatcher or
+	// news.NewEverythingWatcher to create it.
+	Watcher *news.Watcher
+
+	// Heartbeat is the time between two comments that keep idle
+	// connections open behind proxies. Default: 15 seconds
+	Heartbeat time.Duration
+
+	// History is the number of events that are kept for clients
+	// that reconnect with a Last-Event-ID. Default: 100
+	History int
+
+	// Buffer is the number of events that can wait for a slow
+	// client. If the buffer is full the client is disconnected,
+	// the browser reconnects and continues with the Last-Event-ID.
+	// Default: 32
+	Buffer int
+
+	// now can be replaced in tests.
+	now func() time.Time
+
+	once sync.Once
+	mu   sync.Mutex
+
+	// seq starts at the start time in nanoseconds, so that the ids
+	// after a restart are larger than the ones before.
+	seq     uint64
+	history []event
+	subs    map[*subscriber]struct{}
+}
+
+type event struct {
+	id   uint64
+	data []byte
+}
+
+type subscriber struct {
+	ch chan event
+}
+
+// NewHandler creates a handler for the watcher.
+func NewHandler(w *news.Watcher) *Handler {
+	return &Handler{Watcher: w}
+}
+
+func (h *Handler) init() {
+	h.once.Do(func() {
+		if h.Heartbeat <= 0 {
+			h.Heartbeat = 15 * time.Second
+		}
+		if h.History <= 0 {
+			h.History = 100
+		}
+		if h.Buffer <= 0 {
+			h.Buffer = 32
+		}
+		if h.now == nil {
+			h.now = time.Now
+		}
+		h.seq = uint64(h.now().UnixNano())
+		h.subs = make(map[*subscriber]struct{})
+	})
+}
+
+// Run polls the watcher until stop is closed and publishes the new
+// articles.
+func (h *Handler) Run(stop <-chan struct{}) {
+	h.Watcher.Run(stop, func(a news.Article) {
+		h.Publish(a)
+	})
+}
+
+// Publish sends the articles to every client.
+func (h *Handler) Publish(articles ...news.Article) {
+	h.init()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, a := range articles {
+		data, err := json.Marshal(a)
+		if err != nil {
+			continue
+		}
+		h.seq++
+		ev := event{id: h.seq, data: data}
+
+		h.history = append(h.history, ev)
+		if len(h.history) > h.History {
+			h.history = h.history[len(h.history)-h.History:]
+		}
+
+		for sub := range h.subs {
+			select {
+			case sub.ch <- ev:
+			default:
+				// the client is too slow. It reconnects and
+				// gets the missed events from the history.
+				delete(h.subs, sub)
+				close(sub.ch)
+			}
+		}
+	}
+}
+
+// Subscribers returns the number of connected clients.
+func (h *Handler) Subscribers() int {
+	h.init()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// subscribe registers a client and returns the events after the
+// last event id.
+func (h *Handler) subscribe(lastID string) (*subscriber, []event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []event
+	if lastID != "" {
+		last, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil || last > h.seq {
+			// the id is not from this handler. Ids from before
+			// a restart are smaller than the seq and get the
+			// whole history anyway.
+			last = 0
+		}
+		for _, ev := range h.history {
+			if ev.id > last {
+				missed = append(missed, ev)
+			}
+		}
+	}
+
+	sub := &subscriber{ch: make(chan event, h.Buffer)}
+	h.subs[sub] = struct{}{}
+	return sub, missed
+}
+
+func (h *Handler) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// ServeHTTP streams the events until the client disconnects.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.init()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// the query parameter is for EventSource polyfills that
+	// can't set headers
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	sub, missed := h.subscribe(lastID)
+	defer h.unsubscribe(sub)
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // nginx
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range missed {
+		if writeEvent(w, ev) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			if writeEvent(w, ev) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: article\ndata: %s\n\n", ev.id, ev.data)
+	return err
+}
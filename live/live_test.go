@@ -0,0 +1,185 @@
+package live
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// connect opens a stream and returns a func that reads the next
+// event (or comment) as a single string.
+func connect(t *testing.T, url, lastID string) func() string {
+	req, _ := http.NewRequest("GET", url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatal("unexpected content type ", resp.Header.Get("Content-Type"))
+	}
+
+	r := bufio.NewReader(resp.Body)
+	return func() string {
+		var lines []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line == "\n" {
+				return strings.Join(lines, "|")
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+}
+
+func waitFor(t *testing.T, h *Handler, n int) {
+	for i := 0; i < 100 && h.Subscribers() != n; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if h.Subscribers() != n {
+		t.Fatalf("expected %d subscribers but got %d", n, h.Subscribers())
+	}
+}
+
+func TestHandler_FanOut(t *testing.T) {
+	t.Parallel()
+
+	var polls int32
+	w := &news.Watcher{
+		Interval: time.Hour,
+		Query: func() ([]news.Article, *news.ResponseInfo, *news.Exception) {
+			atomic.AddInt32(&polls, 1)
+			return []news.Article{{Title: "A", URL: "https://example.com/a"}}, nil, nil
+		},
+	}
+	h := NewHandler(w)
+	h.now = func() time.Time { return time.Unix(0, 0) }
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close) // after the bodies are closed
+
+	first := connect(t, srv.URL, "")
+	second := connect(t, srv.URL, "")
+	waitFor(t, h, 2)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go h.Run(stop)
+
+	expected := `id: 1|event: article|data: {"source":{"id":"","name":""},"author":"","title":"A","description":"","url":"https://example.com/a","urlToImage":"","publishedAt":"","content":""}`
+	for _, next := range []func() string{first, second} {
+		if ev := next(); ev != expected {
+			t.Fatal("unexpected event ", ev)
+		}
+	}
+	if atomic.LoadInt32(&polls) != 1 {
+		t.Fatal("expected a single poll for both clients")
+	}
+}
+
+func TestHandler_LastEventID(t *testing.T) {
+	t.Parallel()
+
+	h := &Handler{History: 3, now: func() time.Time { return time.Unix(0, 0) }}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	for i := 1; i <= 5; i++ {
+		h.Publish(news.Article{Title: fmt.Sprint(i)})
+	}
+
+	next := connect(t, srv.URL, "3")
+	for _, id := range []string{"id: 4", "id: 5"} {
+		if ev := next(); !strings.HasPrefix(ev, id+"|") {
+			t.Fatalf("expected %q but got %q", id, ev)
+		}
+	}
+
+	// only the last 3 events are kept
+	next = connect(t, srv.URL, "1")
+	if ev := next(); !strings.HasPrefix(ev, "id: 3|") {
+		t.Fatal("expected the oldest event of the history but got ", ev)
+	}
+
+	// an unknown id gets the whole history
+	next = connect(t, srv.URL, "99")
+	if ev := next(); !strings.HasPrefix(ev, "id: 3|") {
+		t.Fatal("expected the whole history but got ", ev)
+	}
+}
+
+func TestHandler_Restart(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	before := &Handler{now: func() time.Time { return start }}
+	for i := 0; i < 5; i++ {
+		before.Publish(news.Article{Title: fmt.Sprint(i)})
+	}
+	lastID := fmt.Sprint(before.seq)
+
+	// the ids of the new handler don't start at 1 again, so the
+	// client doesn't skip the new events
+	after := &Handler{now: func() time.Time { return start.Add(time.Second) }}
+	for i := 0; i < 6; i++ {
+		after.Publish(news.Article{Title: fmt.Sprint("new ", i)})
+	}
+	srv := httptest.NewServer(after)
+	t.Cleanup(srv.Close)
+
+	next := connect(t, srv.URL, lastID)
+	if ev := next(); !strings.Contains(ev, `"title":"new 0"`) {
+		t.Fatal("expected every event after the restart but got ", ev)
+	}
+}
+
+func TestHandler_Heartbeat(t *testing.T) {
+	t.Parallel()
+
+	h := &Handler{Heartbeat: 10 * time.Millisecond}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	next := connect(t, srv.URL, "")
+	if ev := next(); ev != ": heartbeat" {
+		t.Fatal("expected a heartbeat but got ", ev)
+	}
+}
+
+func TestHandler_SlowClient(t *testing.T) {
+	t.Parallel()
+
+	h := &Handler{Buffer: 2}
+	h.init()
+	sub, _ := h.subscribe("")
+
+	h.Publish(news.Article{Title: "1"}, news.Article{Title: "2"})
+	if h.Subscribers() != 1 {
+		t.Fatal("expected the client while the buffer has room")
+	}
+	h.Publish(news.Article{Title: "3"})
+	if h.Subscribers() != 0 {
+		t.Fatal("expected the slow client to be dropped")
+	}
+
+	var received int
+	for range sub.ch {
+		received++
+	}
+	if received != 2 {
+		t.Fatal("expected the buffered events before the close but got ", received)
+	}
+}
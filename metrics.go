@@ -0,0 +1,211 @@
+package news
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestMetrics collects the metrics of every request.
+// Default: nil (no metrics)
+var RequestMetrics *Metrics
+
+// latencyBuckets are the upper bounds of the latency histogram
+// in seconds.
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics counts the requests of one or more clients. It is an
+// http.Handler that writes the metrics in the text format of
+// Prometheus, so it can be scraped directly:
+//
+//	metrics := news.NewMetrics()
+//	client := news.NewClient(news.Config{Metrics: metrics})
+//	http.Handle("/metrics", metrics)
+type Metrics struct {
+	mu sync.Mutex
+
+	// requests is keyed by endpoint, status and code.
+	requests map[[3]string]int
+
+	latency  map[string]*histogram
+	hits     map[string]int
+	misses   map[string]int
+	retries  map[string]int
+	inFlight int
+
+	quota    int
+	hasQuota bool
+}
+
+type histogram struct {
+	buckets []int
+	sum     float64
+	count   int
+}
+
+// NewMetrics creates an empty collector.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests: make(map[[3]string]int),
+		latency:  make(map[string]*histogram),
+		hits:     make(map[string]int),
+		misses:   make(map[string]int),
+		retries:  make(map[string]int),
+	}
+}
+
+// start is called before a request is sent. The returned func
+// records the result.
+func (m *Metrics) start(endpoint string) func(err *Exception, cached bool) {
+	m.mu.Lock()
+	m.inFlight++
+	m.mu.Unlock()
+
+	begin := time.Now()
+	return func(err *Exception, cached bool) {
+		seconds := time.Since(begin).Seconds()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.inFlight--
+
+		status, code := "ok", ""
+		if err != nil {
+			status, code = "error", err.Code
+		}
+		m.requests[[3]string{endpoint, status, code}]++
+
+		h, ok := m.latency[endpoint]
+		if !ok {
+			h = &histogram{buckets: make([]int, len(latencyBuckets))}
+			m.latency[endpoint] = h
+		}
+		for i, le := range latencyBuckets {
+			if seconds <= le {
+				h.buckets[i]++
+			}
+		}
+		h.sum += seconds
+		h.count++
+
+		if err == nil {
+			if cached {
+				m.hits[endpoint]++
+			} else {
+				m.misses[endpoint]++
+			}
+		}
+	}
+}
+
+func (m *Metrics) retry(endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.retries[endpoint]++
+}
+
+// setQuota records the requests that are left, for example
+// of a QuotaLimiter.
+func (m *Metrics) setQuota(remaining int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.quota = remaining
+	m.hasQuota = true
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	header(&b, "newsapi_requests_total", "counter", "Requests to the news api by endpoint, status and error code.")
+	keys := make([][3]string, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.Join(keys[i][:], "\x00") < strings.Join(keys[j][:], "\x00")
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&b, "newsapi_requests_total{endpoint=%s,status=%s,code=%s} %d\n",
+			quote(k[0]), quote(k[1]), quote(k[2]), m.requests[k])
+	}
+
+	header(&b, "newsapi_request_duration_seconds", "histogram", "Latency of the requests to the news api.")
+	for _, endpoint := range sortedKeys(m.latency) {
+		h := m.latency[endpoint]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(&b, "newsapi_request_duration_seconds_bucket{endpoint=%s,le=%s} %d\n",
+				quote(endpoint), quote(strconv.FormatFloat(le, 'g', -1, 64)), h.buckets[i])
+		}
+		fmt.Fprintf(&b, "newsapi_request_duration_seconds_bucket{endpoint=%s,le=\"+Inf\"} %d\n", quote(endpoint), h.count)
+		fmt.Fprintf(&b, "newsapi_request_duration_seconds_sum{endpoint=%s} %s\n", quote(endpoint), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "newsapi_request_duration_seconds_count{endpoint=%s} %d\n", quote(endpoint), h.count)
+	}
+
+	counter(&b, "newsapi_cache_hits_total", "Successful responses that came from the cache of the news api.", m.hits)
+	counter(&b, "newsapi_cache_misses_total", "Successful responses with fresh data.", m.misses)
+	counter(&b, "newsapi_retries_total", "Requests that were sent again because of the retry policy.", m.retries)
+
+	header(&b, "newsapi_in_flight_requests", "gauge", "Requests that are waiting for a response.")
+	fmt.Fprintf(&b, "newsapi_in_flight_requests %d\n", m.inFlight)
+
+	if m.hasQuota {
+		header(&b, "newsapi_quota_remaining", "gauge", "Requests that are left in the window of the limiter.")
+		fmt.Fprintf(&b, "newsapi_quota_remaining %d\n", m.quota)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func header(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func counter(b *strings.Builder, name, help string, values map[string]int) {
+	header(b, name, "counter", help)
+	for _, endpoint := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{endpoint=%s} %d\n", name, quote(endpoint), values[endpoint])
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]int:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*histogram:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quote escapes a label value like the text format expects.
+func quote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return `"` + s + `"`
+}
@@ -0,0 +1,78 @@
+package news
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := NewMetrics()
+	calls := 0
+	c := mockClient(Config{
+		Metrics: metrics,
+		Limiter: NewQuotaLimiter(10, time.Hour),
+		Retry:   RetryPolicy{MaxRetries: 1},
+	}, func(req *http.Request) (*http.Response, error) {
+		calls++
+		json := `{"status":"ok","articles":[]}`
+		header := http.Header{}
+		switch calls {
+		case 1:
+			header.Set("X-Cached-Result", "true")
+		case 2:
+			json = `{"status":"error","code":"unexpectedError"}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
+	c.sleep = func(context.Context, time.Duration) error { return nil }
+
+	c.TopHeadlines(TopHeadlinesOptions{})
+	// fails once and is retried
+	c.Everything(EverythingOptions{})
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	expected := []string{
+		`newsapi_requests_total{endpoint="everything",status="error",code="unexpectedError"} 1`,
+		`newsapi_requests_total{endpoint="everything",status="ok",code=""} 1`,
+		`newsapi_requests_total{endpoint="top-headlines",status="ok",code=""} 1`,
+		`newsapi_request_duration_seconds_count{endpoint="everything"} 2`,
+		`newsapi_request_duration_seconds_bucket{endpoint="top-headlines",le="+Inf"} 1`,
+		`newsapi_cache_hits_total{endpoint="top-headlines"} 1`,
+		`newsapi_cache_misses_total{endpoint="everything"} 1`,
+		`newsapi_retries_total{endpoint="everything"} 1`,
+		`newsapi_in_flight_requests 0`,
+		`newsapi_quota_remaining 7`,
+		`# TYPE newsapi_request_duration_seconds histogram`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected the line %q in:\n%s", line, body)
+		}
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatal("unexpected content type ", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	if q := quote("a\"b\\c\nd"); q != `"a\"b\\c\nd"` {
+		t.Fatal("unexpected escaping ", q)
+	}
+}
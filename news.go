@@ -1,16 +1,26 @@
 package news
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/google/go-querystring/query"
 )
 
+// The package level variables configure the package level functions
+// like TopHeadlines. They are read once by the first call, so set
+// them before the first request (or call SetDefault afterwards). To
+// use different settings at the same time create a Client with
+// NewClient instead.
+
 // APIKey contains the api key required for every request
 // to the news api. Gets added to the query string if its
 // not already included.
@@ -20,10 +30,20 @@ var APIKey string
 // for example "User-Agent": "Golang Client"
 var Headers map[string]string
 
+// ArticlesHook gets called after every successful request that
+// returned articles. The endpoint is for example "everything" and
+// the query contains the parameters without the api key.
+// Use it to archive every result automatically.
+var ArticlesHook func(endpoint string, query url.Values, articles []Article)
+
 // Exception is a representation of a news exception.
 type Exception struct {
 	Code    string `json:"code"` // Error Code
 	Message string `json:"message"`
+
+	// temporary is set for errors that could go away by
+	// waiting, see RetryPolicy.
+	temporary bool
 }
 
 // Error stringifies the error
@@ -44,45 +64,119 @@ var (
 	Timeout = 10 * time.Second
 
 	// HTTPClient is the http client that is used for every
-	// request. If you want to have a different timeout
-	// overwrite the variable before using it.
-	HTTPClient httpClient = &http.Client{Timeout: Timeout}
+	// request. If it is nil a http.Client with the Timeout
+	// is used.
+	HTTPClient httpClient
+
+	// MaxBodySize is the maximum size of a response body in bytes.
+	// Larger responses are abandoned with an error.
+	MaxBodySize int64 = DefaultMaxBodySize
+
+	// ValidateOptions checks the options with Validate before
+	// sending a request, see TopHeadlinesOptions.Validate.
+	ValidateOptions bool
 )
 
-// getJSON is fetching json from an api endpoint.
-func getJSON(url string, target interface{}, headers map[string]string) (http.Header, error) {
-	fmt.Println(url)
+// DefaultMaxBodySize is the default of MaxBodySize (16 MB).
+const DefaultMaxBodySize = 16 << 20
+
+// errBodyTooLarge is returned while reading a body that is
+// larger than MaxBodySize.
+var errBodyTooLarge = errors.New("the response body is larger than the maximum body size")
 
-	req, err := http.NewRequest("GET", url, nil)
+// limitedBody returns errBodyTooLarge once more than max
+// bytes were read.
+type limitedBody struct {
+	r   io.Reader
+	max int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.max < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > l.max+1 {
+		p = p[:l.max+1]
+	}
+	n, err := l.r.Read(p)
+	l.max -= int64(n)
+	if l.max < 0 {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
+
+// decoder reads the body of a successful response into res.
+type decoder func(body io.Reader, res *networkResult) error
+
+// decodeAll reads the whole body at once.
+func decodeAll(body io.Reader, res *networkResult) error {
+	return json.NewDecoder(body).Decode(res)
+}
+
+// getJSON is fetching json from an api endpoint.
+func (c *Client) getJSON(ctx context.Context, url string, res *networkResult, decode decoder, headers map[string]string) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, errors.New("[new request] " + err.Error())
 	}
 
+	// the body is decompressed by decodeBody, the user can
+	// still overwrite the header.
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
 	// adding the headers that the user specified to the request
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
 
-	resp, err := HTTPClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, redactError(err)
 	}
+	// the body is closed on every path, otherwise the
+	// connection can't be reused.
+	defer closeBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		e := fmt.Sprint("status code ", resp.StatusCode, " != 200")
-		return nil, errors.New(e)
+		// the api also explains the error in the body, for
+		// example with the code 'rateLimited'.
+		if resp.Body != nil {
+			if r, err := decodeBody(resp); err == nil {
+				body, _ := ioutil.ReadAll(&limitedBody{r, c.maxBodySize})
+
+				var apiErr networkResult
+				if json.Unmarshal(body, &apiErr) == nil && apiErr.Status == "error" {
+					*res = apiErr
+					return resp.Header, nil
+				}
+			}
+		}
+
+		return nil, statusError(resp.StatusCode)
 	}
 
 	if resp.Body == nil {
 		return nil, errors.New("the response body is nil")
 	}
 
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(target)
+	r, err := decodeBody(resp)
+	if err != nil {
+		return nil, errors.New("[decompressing] " + err.Error())
+	}
+	err = decode(&limitedBody{r, c.maxBodySize}, res)
 
 	return resp.Header, err
 }
 
+// statusError is returned for a response that is not 200 and
+// has no error code of the api in the body.
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprint("status code ", int(e), " != 200")
+}
+
 // networkResult is the standard result from the rest api.
 type networkResult struct {
 	Status string `json:"status"`
@@ -96,6 +190,10 @@ type networkResult struct {
 
 	// - - or sources - - //
 	Sources []Source `json:"sources"`
+
+	// streamed is the number of elements that were passed to
+	// the callback of a streaming request.
+	streamed int
 }
 
 // ResponseInfo contains more information about the response
@@ -118,13 +216,76 @@ type ResponseInfo struct {
 	TotalResults int
 }
 
-func fetch(url string, opt interface{}, forceFreshData bool) (networkResult, *ResponseInfo, *Exception) {
+// send asks the limiter and then sends one request.
+func (c *Client) send(ctx context.Context, url string, res *networkResult, decode decoder, headers map[string]string) (http.Header, *Exception) {
+	// a canceled request does not use up the quota
+	if err := ctx.Err(); err != nil {
+		return nil, &Exception{
+			Code:    "[requesting json]",
+			Message: err.Error(),
+		}
+	}
+	if c.limiter != nil {
+		if err := waitLimiter(ctx, c.limiter); err != nil {
+			return nil, &Exception{
+				Code:    "[limiter]",
+				Message: err.Error(),
+			}
+		}
+	}
+
+	respHeaders, err := c.getJSON(ctx, url, res, decode, headers)
+	if cbErr, ok := err.(callbackError); ok {
+		return nil, &Exception{
+			Code:    "[callback]",
+			Message: cbErr.err.Error(),
+		}
+	}
+	if err != nil {
+		return nil, &Exception{
+			Code:      "[requesting json]",
+			Message:   err.Error(),
+			temporary: ctx.Err() == nil && temporary(err),
+		}
+	}
+	if res.Status != "ok" {
+		return nil, &Exception{
+			Code:    res.Code,
+			Message: res.Message,
+		}
+	}
+	return respHeaders, nil
+}
+
+// sendMeasured is send with metrics.
+func (c *Client) sendMeasured(ctx context.Context, endpoint, url string, res *networkResult, decode decoder, headers map[string]string) (http.Header, *Exception) {
+	if c.metrics == nil {
+		return c.send(ctx, url, res, decode, headers)
+	}
+
+	done := c.metrics.start(endpoint)
+	respHeaders, exc := c.send(ctx, url, res, decode, headers)
+	done(exc, respHeaders.Get("X-Cached-Result") == "true")
+
+	if l, ok := c.limiter.(interface{ Remaining() int }); ok {
+		c.metrics.setQuota(l.Remaining())
+	}
+	return respHeaders, exc
+}
+
+// fetch requests the endpoint (for example "everything") with the
+// options converted to the query string.
+func (c *Client) fetch(endpoint string, opt interface{}, forceFreshData bool) (networkResult, *ResponseInfo, *Exception) {
+	return c.request(endpoint, opt, forceFreshData, decodeAll)
+}
+
+func (c *Client) request(endpoint string, opt interface{}, forceFreshData bool, decode decoder) (networkResult, *ResponseInfo, *Exception) {
 	var res networkResult
 
 	// copy the values from the map over into the new one.
 	// -> https://stackoverflow.com/a/23058707
 	reqHeaders := make(map[string]string)
-	for k, v := range Headers {
+	for k, v := range c.headers {
 		reqHeaders[k] = v
 	}
 
@@ -143,24 +304,83 @@ func fetch(url string, opt interface{}, forceFreshData bool) (networkResult, *Re
 		}
 	}
 
-	// attach the query parameter to the url
-	url = url + "?" + v.Encode()
+	// the key pool is only used if no key was set
+	useKeys := c.keys != nil && v.Get("apiKey") == ""
 
-	headers, err := getJSON(url, &res, reqHeaders)
-	if err != nil {
-		return res, nil, &Exception{
-			Code:    "[requesting json]",
-			Message: err.Error(),
+	// the span must not contain the key
+	redacted := url.Values{}
+	for key, values := range v {
+		if key != "apiKey" {
+			redacted[key] = values
 		}
 	}
-	if res.Status != "ok" {
-		return res, nil, &Exception{
-			Code:    res.Code,
-			Message: res.Message,
+	ctx, span := c.startSpan(endpoint, redacted)
+
+	var headers http.Header
+	var exc *Exception
+	attempts := 0
+	for attempt := 0; ; attempt++ {
+		attempts++
+		if useKeys {
+			key, err := c.keys.pick()
+			if err != nil {
+				// the error of the last key says more
+				if exc == nil {
+					exc = &Exception{
+						Code:    "[key pool]",
+						Message: err.Error(),
+					}
+				}
+				break
+			}
+			v.Set("apiKey", key)
+		}
+
+		// attach the query parameter to the url
+		url := c.baseURL + "/" + endpoint + "?" + v.Encode()
+
+		res = networkResult{}
+		headers, exc = c.sendMeasured(ctx, endpoint, url, &res, decode, reqHeaders)
+
+		// the callback already got some of the elements
+		if res.streamed > 0 {
+			break
+		}
+		// the caller gave up, a retry or another key won't help
+		if ctx.Err() != nil {
+			break
+		}
+		if useKeys && c.keys.report(v.Get("apiKey"), exc) {
+			// try the next key right away, that is not a retry
+			attempt--
+			continue
+		}
+		if exc == nil || attempt >= c.retry.MaxRetries || !c.retry.retryable(exc) {
+			break
+		}
+		if c.metrics != nil {
+			c.metrics.retry(endpoint)
+		}
+		if err := c.sleep(ctx, c.retry.backoff(attempt)); err != nil {
+			exc = &Exception{
+				Code:    "[requesting json]",
+				Message: err.Error(),
+			}
+			break
 		}
 	}
 
 	isCached := headers.Get("X-Cached-Result") == "true"
+	endSpan(span, res, isCached, attempts, exc)
+
+	if exc != nil {
+		return res, nil, exc
+	}
+
+	if c.articlesHook != nil && len(res.Articles) > 0 {
+		c.articlesHook(endpoint, redacted, res.Articles)
+	}
+
 	expires := headers.Get("X-Cache-Expires")
 	remaining := headers.Get("X-Cache-Remaining")
 	date := headers.Get("Date")
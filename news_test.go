@@ -5,24 +5,25 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 )
 
 func TestFetch_BadQueryString(t *testing.T) {
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			return &http.Response{
-				StatusCode: http.StatusOK,
-				Body:       ioutil.NopCloser(bytes.NewBufferString("hello world")),
-			}, nil
-		},
-	}
+	t.Parallel()
+
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString("hello world")),
+		}, nil
+	})
 
 	url := "url"
 	query := map[string]string{"should": "fail"}
 
-	_, _, err := fetch(url, query, false)
+	_, _, err := c.fetch(url, query, false)
 	if err == nil {
 		t.Fatal("expected error because of the bad query")
 	}
@@ -31,11 +32,11 @@ func TestFetch_BadQueryString(t *testing.T) {
 	}
 }
 func TestFetch_NetworkError(t *testing.T) {
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			return nil, errors.New("some error")
-		},
-	}
+	t.Parallel()
+
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("some error")
+	})
 
 	url := "url"
 	query := struct {
@@ -44,7 +45,7 @@ func TestFetch_NetworkError(t *testing.T) {
 		Name: "test",
 	}
 
-	_, _, err := fetch(url, query, false)
+	_, _, err := c.fetch(url, query, false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -54,13 +55,13 @@ func TestFetch_NetworkError(t *testing.T) {
 }
 
 func TestFetch_StatusCode(t *testing.T) {
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			return &http.Response{
-				StatusCode: http.StatusBadRequest,
-			}, nil
-		},
-	}
+	t.Parallel()
+
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+		}, nil
+	})
 
 	url := "url"
 	query := struct {
@@ -69,7 +70,7 @@ func TestFetch_StatusCode(t *testing.T) {
 		Name: "test",
 	}
 
-	_, _, err := fetch(url, query, false)
+	_, _, err := c.fetch(url, query, false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -77,3 +78,39 @@ func TestFetch_StatusCode(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestFetch_ArticlesHook(t *testing.T) {
+	t.Parallel()
+
+	json := `{"status":"ok","totalResults":1,"articles":[{"title":"Title 1"}]}`
+
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
+
+	var called bool
+	hook := func(endpoint string, query url.Values, articles []Article) {
+		called = true
+		if endpoint != "everything" {
+			t.Fatal("expected the endpoint 'everything' but got ", endpoint)
+		}
+		if query.Get("q") != "bitcoin" || query.Get("apiKey") != "" {
+			t.Fatal("expected the query without the api key but got ", query)
+		}
+		if len(articles) != 1 {
+			t.Fatal("expected 1 article but got ", len(articles))
+		}
+	}
+	c = NewClient(Config{HTTPClient: c.httpClient, ArticlesHook: hook})
+
+	_, _, err := c.Everything(EverythingOptions{Query: "bitcoin", APIKey: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Fatal("expected the hook to be called")
+	}
+}
@@ -0,0 +1,86 @@
+package news
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// MaxBackoff is the longest time waited before a retry, no matter
+// how often the backoff was doubled.
+const MaxBackoff = 5 * time.Minute
+
+// RetryPolicy decides if a failed request is sent again. Only
+// errors that could go away by waiting are retried: timeouts,
+// refused and reset connections, the status codes 429 and 5xx,
+// 'rateLimited' and 'unexpectedError'. Invalid responses, other
+// errors of the http client (like an invalid certificate) and a
+// canceled context are never retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first
+	// request. Default: 0 (no retries)
+	MaxRetries int
+
+	// Backoff is the time to wait before the first retry. It
+	// doubles for every following retry up to MaxBackoff.
+	// Default: 1 second
+	Backoff time.Duration
+}
+
+// Retry is the retry policy used for every request.
+var Retry RetryPolicy
+
+func (p RetryPolicy) retryable(err *Exception) bool {
+	switch err.Code {
+	case "rateLimited", "unexpectedError":
+		return true
+	}
+	return err.temporary
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	if backoff >= MaxBackoff {
+		return MaxBackoff
+	}
+	for ; attempt > 0; attempt-- {
+		backoff *= 2
+		if backoff >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// temporary reports if the error of a request is worth a retry.
+// Every error of the http client is a url.Error (and with that a
+// net.Error), so only timeouts, refused and reset connections and
+// connections that were closed in the middle of the response are
+// retried. Errors like an invalid certificate or an unsupported
+// scheme would fail the same way, and so would an invalid body.
+func temporary(err error) bool {
+	var status statusError
+	if errors.As(err, &status) {
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	// the decoder returns the same error for a truncated body,
+	// so only the errors of the http client count.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,212 @@
+package news
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			if requests < 3 {
+				return &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"error","code":"rateLimited","message":"slow down"}`)),
+				}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"ok","sources":[]}`)),
+			}, nil
+		},
+	}
+
+	c := NewClient(Config{
+		HTTPClient: client,
+		Retry:      RetryPolicy{MaxRetries: 2, Backoff: time.Second},
+	})
+	var waited []time.Duration
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		waited = append(waited, d)
+		return nil
+	}
+
+	_, _, err := c.Sources(SourcesOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Fatal("expected 3 requests but got ", requests)
+	}
+	if len(waited) != 2 || waited[0] != time.Second || waited[1] != 2*time.Second {
+		t.Fatal("expected an exponential backoff but got ", waited)
+	}
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			return &http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"error","code":"apiKeyInvalid","message":"invalid"}`)),
+			}, nil
+		},
+	}
+
+	c := NewClient(Config{
+		HTTPClient: client,
+		Retry:      RetryPolicy{MaxRetries: 2},
+	})
+	c.sleep = func(context.Context, time.Duration) error { return nil }
+
+	_, _, err := c.Sources(SourcesOptions{})
+	if err == nil || err.Code != "apiKeyInvalid" {
+		t.Fatal("expected the error code of the api but got ", err)
+	}
+	if requests != 1 {
+		t.Fatal("expected no retries but got ", requests)
+	}
+}
+
+func TestRetry_Responses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status   int
+		body     string
+		requests int
+	}{
+		{http.StatusServiceUnavailable, `<html>down</html>`, 3},
+		{http.StatusTooManyRequests, ``, 3},
+		{http.StatusBadRequest, `<html>bad</html>`, 1},
+		{http.StatusOK, `{"status":"ok","sources":[`, 1},
+	}
+
+	for _, test := range tests {
+		var requests int
+		client := &ClientMock{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				requests++
+				return &http.Response{
+					StatusCode: test.status,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(test.body)),
+				}, nil
+			},
+		}
+
+		c := NewClient(Config{
+			HTTPClient: client,
+			Retry:      RetryPolicy{MaxRetries: 2},
+		})
+		c.sleep = func(context.Context, time.Duration) error { return nil }
+
+		_, _, err := c.Sources(SourcesOptions{})
+		if err == nil {
+			t.Fatal("expected an error for ", test.status, " ", test.body)
+		}
+		if requests != test.requests {
+			t.Fatal("expected ", test.requests, " requests for ", test.status, " ", test.body, " but got ", requests)
+		}
+	}
+}
+
+func TestRetry_MaxBackoff(t *testing.T) {
+	t.Parallel()
+
+	p := RetryPolicy{Backoff: time.Minute}
+	if d := p.backoff(2); d != 4*time.Minute {
+		t.Fatal("expected 4m but got ", d)
+	}
+	for _, attempt := range []int{3, 10, 64, 1000} {
+		if d := p.backoff(attempt); d != MaxBackoff {
+			t.Fatal("expected the maximum backoff for attempt ", attempt, " but got ", d)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetry_NetworkErrors(t *testing.T) {
+	t.Parallel()
+
+	tls := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok","sources":[]}`))
+	}))
+	tls.Config.ErrorLog = log.New(ioutil.Discard, "", 0) // the failed handshakes
+	tls.StartTLS()
+	defer tls.Close()
+
+	// nothing listens on the address anymore
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + l.Addr().String()
+	l.Close()
+
+	fail := func(err error) httpClient {
+		return &http.Client{Transport: roundTripper(func(req *http.Request) (*http.Response, error) {
+			return nil, err
+		})}
+	}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		client  httpClient
+		retries int
+	}{
+		// the certificate of the test server is not trusted
+		{"tls", tls.URL, &http.Client{}, 0},
+		{"scheme", "ftp://example.com/v2", &http.Client{}, 0},
+		{"refused", refused, &http.Client{}, 2},
+		{"reset", "", fail(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), 2},
+		{"timeout", "", fail(timeoutError{}), 2},
+		{"eof", "", fail(io.ErrUnexpectedEOF), 2},
+		{"other", "", fail(errors.New("something else")), 0},
+	}
+
+	for _, test := range tests {
+		c := NewClient(Config{
+			BaseURL:    test.baseURL,
+			HTTPClient: test.client,
+			Retry:      RetryPolicy{MaxRetries: 2},
+		})
+		var retries int
+		c.sleep = func(context.Context, time.Duration) error {
+			retries++
+			return nil
+		}
+
+		_, _, err := c.Sources(SourcesOptions{})
+		if err == nil {
+			t.Fatal("expected an error for ", test.name)
+		}
+		if retries != test.retries {
+			t.Fatal("expected ", test.retries, " retries for ", test.name, " but got ", retries, ": ", err)
+		}
+	}
+}
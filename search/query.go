@@ -0,0 +1,271 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// node is a part of a parsed query.
+type node interface{}
+
+type termNode struct {
+	term string
+}
+
+type phraseNode struct {
+	terms []string
+}
+
+type andNode struct {
+	children []node
+}
+
+type orNode struct {
+	children []node
+}
+
+type notNode struct {
+	child node
+}
+
+// parse parses a query in the syntax of the `q` parameter of the
+// news api:
+//
+//   - surround phrases with quotes for an exact match: "climate change"
+//   - prepend words or phrases that must appear with a +: +bitcoin
+//   - prepend words that must not appear with a -: -ethereum
+//   - use AND / OR / NOT and group with parenthesis:
+//     crypto AND (ethereum OR litecoin) NOT bitcoin
+//
+// Words without an operator between them must all appear.
+func parse(q string) (node, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in the query", p.tokens[p.pos].value)
+	}
+	if n == nil {
+		return nil, fmt.Errorf("the query is empty")
+	}
+	return n, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenPhrase
+	tokenOpen
+	tokenClose
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenPlus
+	tokenMinus
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+func lex(q string) ([]token, error) {
+	var tokens []token
+	runes := []rune(q)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenOpen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenClose, ")"})
+			i++
+		case r == '+' || r == '-':
+			// only an operator at the start of a word
+			if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+				kind := tokenPlus
+				if r == '-' {
+					kind = tokenMinus
+				}
+				tokens = append(tokens, token{kind, string(r)})
+			}
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("missing closing quote in the query")
+			}
+			tokens = append(tokens, token{tokenPhrase, string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '(' && runes[end] != ')' && runes[end] != '"' {
+				end++
+			}
+			word := string(runes[i:end])
+			switch word {
+			case "AND":
+				tokens = append(tokens, token{tokenAnd, word})
+			case "OR":
+				tokens = append(tokens, token{tokenOr, word})
+			case "NOT":
+				tokens = append(tokens, token{tokenNot, word})
+			default:
+				tokens = append(tokens, token{tokenWord, word})
+			}
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// or := and (OR and)*
+func (p *parser) or() (node, error) {
+	var children []node
+	for {
+		n, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			children = append(children, n)
+		}
+
+		t, ok := p.peek()
+		if !ok || t.kind != tokenOr {
+			break
+		}
+		p.pos++
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	if len(children) == 0 {
+		return nil, nil
+	}
+	return orNode{children}, nil
+}
+
+// and := unary ((AND | NOT)? unary)*
+func (p *parser) and() (node, error) {
+	var children []node
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == tokenOr || t.kind == tokenClose {
+			break
+		}
+		if t.kind == tokenAnd {
+			p.pos++
+			continue
+		}
+
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			children = append(children, n)
+		}
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	if len(children) == 0 {
+		return nil, nil
+	}
+	return andNode{children}, nil
+}
+
+// unary := (NOT | + | -)? primary
+func (p *parser) unary() (node, error) {
+	t, _ := p.peek()
+	switch t.kind {
+	case tokenNot, tokenMinus:
+		p.pos++
+		n, err := p.primary()
+		if err != nil || n == nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokenPlus:
+		p.pos++
+	}
+	return p.primary()
+}
+
+// primary := word | phrase | ( or )
+func (p *parser) primary() (node, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of the query")
+	}
+	p.pos++
+
+	switch t.kind {
+	case tokenWord:
+		terms := tokenize(t.value)
+		switch len(terms) {
+		case 0:
+			return nil, nil
+		case 1:
+			return termNode{terms[0]}, nil
+		}
+		// words like "e-mail" are indexed as multiple terms
+		return phraseNode{terms}, nil
+	case tokenPhrase:
+		terms := tokenize(t.value)
+		if len(terms) == 0 {
+			return nil, nil
+		}
+		return phraseNode{terms}, nil
+	case tokenOpen:
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.peek(); !ok || t.kind != tokenClose {
+			return nil, fmt.Errorf("missing closing parenthesis in the query")
+		}
+		p.pos++
+		return n, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in the query", t.value)
+}
+
+// tokenize splits the text into lowercase terms.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,60 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected node
+	}{
+		{
+			query:    "Bitcoin",
+			expected: termNode{"bitcoin"},
+		},
+		{
+			query:    "bitcoin ethereum",
+			expected: andNode{[]node{termNode{"bitcoin"}, termNode{"ethereum"}}},
+		},
+		{
+			query:    `"climate change"`,
+			expected: phraseNode{[]string{"climate", "change"}},
+		},
+		{
+			query:    "+bitcoin -ethereum",
+			expected: andNode{[]node{termNode{"bitcoin"}, notNode{termNode{"ethereum"}}}},
+		},
+		{
+			query: "crypto AND (ethereum OR litecoin) NOT bitcoin",
+			expected: andNode{[]node{
+				termNode{"crypto"},
+				orNode{[]node{termNode{"ethereum"}, termNode{"litecoin"}}},
+				notNode{termNode{"bitcoin"}},
+			}},
+		},
+		{
+			query:    "e-mail",
+			expected: phraseNode{[]string{"e", "mail"}},
+		},
+	}
+
+	for _, test := range tests {
+		n, err := parse(test.query)
+		if err != nil {
+			t.Fatal(test.query, ": ", err)
+		}
+		if !reflect.DeepEqual(n, test.expected) {
+			t.Fatalf("%s: expected %+v but got %+v", test.query, test.expected, n)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, query := range []string{"", `"climate change`, "(bitcoin OR ethereum", "bitcoin)"} {
+		if _, err := parse(query); err == nil {
+			t.Fatal("expected an error for the query ", query)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+// Package search is an offline full-text search over articles,
+// for example the ones in an archive. It understands the same
+// query syntax as the `q` parameter of the news api so that a
+// saved Everything query can run against the local articles
+// without spending any quota.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+	"github.com/JohannesKaufmann/News-API-go/archive"
+)
+
+// the parameters of the BM25 ranking.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// fieldGap is left between the positions of two fields so that
+// phrases can't match across the end of the title and the
+// start of the description.
+const fieldGap = 1000
+
+type document struct {
+	article   news.Article
+	language  string
+	published time.Time
+	length    int
+}
+
+// Index is an inverted index over the Title, Description and
+// Content of articles.
+type Index struct {
+	mu   sync.RWMutex
+	docs []document
+	urls map[string]bool
+
+	// postings maps a term to the documents it appears in
+	// and the positions in the document.
+	postings map[string]map[int][]int
+
+	totalLength int
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{
+		urls:     make(map[string]bool),
+		postings: make(map[string]map[int][]int),
+	}
+}
+
+// FromArchive creates an index with every article of the archive.
+func FromArchive(a *archive.Archive) *Index {
+	idx := NewIndex()
+	for _, r := range a.Find(archive.Filter{}) {
+		idx.Add(r.Article, r.Language)
+	}
+	return idx
+}
+
+// Len returns the number of indexed articles.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.docs)
+}
+
+// Add adds the article to the index. The language is the 2-letter
+// ISO-639-1 code and can be empty. Articles with an url that is
+// already indexed are ignored.
+func (idx *Index) Add(article news.Article, language string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if key := article.CanonicalURL(); key != "" {
+		if idx.urls[key] {
+			return
+		}
+		idx.urls[key] = true
+	}
+
+	id := len(idx.docs)
+	doc := document{
+		article:  article,
+		language: language,
+	}
+	doc.published, _ = time.Parse(time.RFC3339, article.PublishedAt)
+
+	// a field starts after the end of the previous one, a fixed
+	// offset per field would overlap with long fields
+	offset := 0
+	for _, field := range []string{article.Title, article.Description, article.Content} {
+		terms := tokenize(field)
+		for pos, term := range terms {
+			postings := idx.postings[term]
+			if postings == nil {
+				postings = make(map[int][]int)
+				idx.postings[term] = postings
+			}
+			postings[id] = append(postings[id], offset+pos)
+			doc.length++
+		}
+		offset += len(terms) + fieldGap
+	}
+
+	idx.docs = append(idx.docs, doc)
+	idx.totalLength += doc.length
+}
+
+// Filter narrows down the result of Search. Empty fields match
+// every article.
+type Filter struct {
+	// From and To limit the PublishedAt date (inclusive).
+	From time.Time
+	To   time.Time
+
+	// Sources contains the ids or names of the sources.
+	Sources []string
+
+	Language string
+
+	// Limit is the maximum number of results. Default: no limit
+	Limit int
+}
+
+func (f Filter) match(doc document) bool {
+	if !f.From.IsZero() && (doc.published.IsZero() || doc.published.Before(f.From)) {
+		return false
+	}
+	if !f.To.IsZero() && (doc.published.IsZero() || doc.published.After(f.To)) {
+		return false
+	}
+	if len(f.Sources) > 0 {
+		found := false
+		for _, s := range f.Sources {
+			if s == doc.article.Source.ID || s == doc.article.Source.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Language != "" && !strings.EqualFold(f.Language, doc.language) {
+		return false
+	}
+	return true
+}
+
+// Search returns the articles that match the query, the best
+// match first.
+func (idx *Index) Search(q string, f Filter) ([]news.Article, error) {
+	query, err := parse(q)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	type result struct {
+		id    int
+		score float64
+	}
+	var results []result
+	df := idx.phraseFrequencies(query, make(map[string]int))
+	for id := range idx.match(query) {
+		if !f.match(idx.docs[id]) {
+			continue
+		}
+		results = append(results, result{id, idx.score(query, id, df)})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		// the newest article first, then the order they were added
+		pi, pj := idx.docs[results[i].id].published, idx.docs[results[j].id].published
+		if !pi.Equal(pj) {
+			return pi.After(pj)
+		}
+		return results[i].id < results[j].id
+	})
+
+	if f.Limit > 0 && len(results) > f.Limit {
+		results = results[:f.Limit]
+	}
+
+	articles := make([]news.Article, len(results))
+	for i, r := range results {
+		articles[i] = idx.docs[r.id].article
+	}
+	return articles, nil
+}
+
+type set map[int]bool
+
+// match returns the ids of the documents that match the node.
+func (idx *Index) match(n node) set {
+	switch n := n.(type) {
+	case termNode:
+		s := make(set)
+		for id := range idx.postings[n.term] {
+			s[id] = true
+		}
+		return s
+
+	case phraseNode:
+		s := make(set)
+		for id := range idx.postings[n.terms[0]] {
+			if idx.phraseCount(n, id) > 0 {
+				s[id] = true
+			}
+		}
+		return s
+
+	case orNode:
+		s := make(set)
+		for _, child := range n.children {
+			for id := range idx.match(child) {
+				s[id] = true
+			}
+		}
+		return s
+
+	case andNode:
+		var s set
+		var excluded []set
+		for _, child := range n.children {
+			if not, ok := child.(notNode); ok {
+				excluded = append(excluded, idx.match(not.child))
+				continue
+			}
+
+			m := idx.match(child)
+			if s == nil {
+				s = m
+				continue
+			}
+			for id := range s {
+				if !m[id] {
+					delete(s, id)
+				}
+			}
+		}
+		if s == nil {
+			// only negations, they exclude from every document
+			s = idx.all()
+		}
+		for _, ex := range excluded {
+			for id := range ex {
+				delete(s, id)
+			}
+		}
+		return s
+
+	case notNode:
+		s := idx.all()
+		for id := range idx.match(n.child) {
+			delete(s, id)
+		}
+		return s
+	}
+	return set{}
+}
+
+func (idx *Index) all() set {
+	s := make(set, len(idx.docs))
+	for id := range idx.docs {
+		s[id] = true
+	}
+	return s
+}
+
+// phraseCount returns how often the terms appear one after
+// another in the document.
+func (idx *Index) phraseCount(n phraseNode, id int) int {
+	count := 0
+	for _, start := range idx.postings[n.terms[0]][id] {
+		found := true
+		for i, term := range n.terms[1:] {
+			if !containsInt(idx.postings[term][id], start+i+1) {
+				found = false
+				break
+			}
+		}
+		if found {
+			count++
+		}
+	}
+	return count
+}
+
+// phraseFrequencies counts the documents of every phrase of the
+// query once, so that scoring a document doesn't scan the others.
+func (idx *Index) phraseFrequencies(n node, df map[string]int) map[string]int {
+	switch n := n.(type) {
+	case phraseNode:
+		key := strings.Join(n.terms, " ")
+		if _, ok := df[key]; ok {
+			return df
+		}
+		for doc := range idx.postings[n.terms[0]] {
+			if idx.phraseCount(n, doc) > 0 {
+				df[key]++
+			}
+		}
+	case andNode:
+		for _, child := range n.children {
+			idx.phraseFrequencies(child, df)
+		}
+	case orNode:
+		for _, child := range n.children {
+			idx.phraseFrequencies(child, df)
+		}
+	}
+	return df
+}
+
+// score ranks the document with BM25. Only the terms that are
+// not negated add to the score. df contains the document
+// frequencies of the phrases.
+func (idx *Index) score(n node, id int, df map[string]int) float64 {
+	switch n := n.(type) {
+	case termNode:
+		return idx.bm25(len(idx.postings[n.term][id]), len(idx.postings[n.term]), id)
+	case phraseNode:
+		return float64(len(n.terms)) * idx.bm25(idx.phraseCount(n, id), df[strings.Join(n.terms, " ")], id)
+	case andNode:
+		total := 0.0
+		for _, child := range n.children {
+			total += idx.score(child, id, df)
+		}
+		return total
+	case orNode:
+		total := 0.0
+		for _, child := range n.children {
+			total += idx.score(child, id, df)
+		}
+		return total
+	}
+	return 0
+}
+
+func (idx *Index) bm25(tf, df, id int) float64 {
+	if tf == 0 || df == 0 {
+		return 0
+	}
+
+	n := float64(len(idx.docs))
+	idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+	avg := float64(idx.totalLength) / n
+	length := float64(idx.docs[id].length)
+
+	return idf * float64(tf) * (k1 + 1) / (float64(tf) + k1*(1-b+b*length/avg))
+}
+
+func containsInt(list []int, i int) bool {
+	for _, item := range list {
+		if item == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package search
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+	"github.com/JohannesKaufmann/News-API-go/archive"
+)
+
+func testIndex() *Index {
+	idx := NewIndex()
+	idx.Add(news.Article{
+		Source:      news.ArticleSource{ID: "techcrunch", Name: "TechCrunch"},
+		Title:       "Bitcoin hits a new high",
+		Description: "The price of bitcoin is rising again.",
+		URL:         "https://techcrunch.com/1",
+		PublishedAt: "2017-12-18T10:00:00Z",
+	}, "en")
+	idx.Add(news.Article{
+		Source:      news.ArticleSource{ID: "techcrunch", Name: "TechCrunch"},
+		Title:       "Ethereum and bitcoin",
+		Description: "A comparison of crypto currencies.",
+		URL:         "https://techcrunch.com/2",
+		PublishedAt: "2017-12-17T10:00:00Z",
+	}, "en")
+	idx.Add(news.Article{
+		Source:      news.ArticleSource{Name: "Sueddeutsche.de"},
+		Title:       "Klimawandel",
+		Description: "Climate change in Germany",
+		Content:     "Bitcoin mining uses a lot of energy.",
+		URL:         "http://www.sueddeutsche.de/3",
+		PublishedAt: "2017-11-15T17:49:05Z",
+	}, "de")
+	return idx
+}
+
+func urls(articles []news.Article) []string {
+	var result []string
+	for _, a := range articles {
+		result = append(result, a.URL)
+	}
+	return result
+}
+
+func TestSearch_Ranking(t *testing.T) {
+	articles, err := testIndex().Search("bitcoin", Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(articles) != 3 {
+		t.Fatal("expected 3 articles but got ", urls(articles))
+	}
+	// bitcoin appears twice in the first article
+	if articles[0].URL != "https://techcrunch.com/1" {
+		t.Fatal("expected the first article to rank highest but got ", urls(articles))
+	}
+}
+
+func TestSearch_Operators(t *testing.T) {
+	idx := testIndex()
+
+	tests := map[string][]string{
+		"bitcoin -ethereum":                {"https://techcrunch.com/1", "http://www.sueddeutsche.de/3"},
+		"bitcoin AND ethereum":             {"https://techcrunch.com/2"},
+		"ethereum OR klimawandel":          {"https://techcrunch.com/2", "http://www.sueddeutsche.de/3"},
+		`"climate change"`:                 {"http://www.sueddeutsche.de/3"},
+		`"change climate"`:                 nil,
+		"bitcoin NOT (ethereum OR energy)": {"https://techcrunch.com/1"},
+		// a phrase can't span two fields
+		`"klimawandel climate"`: nil,
+	}
+
+	for query, expected := range tests {
+		articles, err := idx.Search(query, Filter{})
+		if err != nil {
+			t.Fatal(query, ": ", err)
+		}
+
+		got := urls(articles)
+		if len(got) != len(expected) {
+			t.Fatalf("%s: expected %v but got %v", query, expected, got)
+		}
+		for _, url := range expected {
+			found := false
+			for _, g := range got {
+				found = found || g == url
+			}
+			if !found {
+				t.Fatalf("%s: expected %v but got %v", query, expected, got)
+			}
+		}
+	}
+}
+
+func TestSearch_LongField(t *testing.T) {
+	idx := NewIndex()
+
+	// the description is longer than the gap between two fields
+	idx.Add(news.Article{
+		Title:       "Climate",
+		Description: strings.Repeat("word ", 999) + "end " + strings.Repeat("word ", 200),
+		Content:     "change",
+		URL:         "https://example.com/1",
+	}, "en")
+
+	articles, _ := idx.Search(`"end change"`, Filter{})
+	if len(articles) != 0 {
+		t.Fatal("expected no phrase across the description and the content but got ", urls(articles))
+	}
+	articles, _ = idx.Search(`"word end"`, Filter{})
+	if len(articles) != 1 {
+		t.Fatal("expected the phrase in the long description but got ", urls(articles))
+	}
+}
+
+func TestSearch_Filter(t *testing.T) {
+	idx := testIndex()
+
+	articles, _ := idx.Search("bitcoin", Filter{Language: "de"})
+	if len(articles) != 1 || articles[0].Source.Name != "Sueddeutsche.de" {
+		t.Fatal("expected only the german article but got ", urls(articles))
+	}
+
+	articles, _ = idx.Search("bitcoin", Filter{Sources: []string{"techcrunch"}, Limit: 1})
+	if len(articles) != 1 || articles[0].URL != "https://techcrunch.com/1" {
+		t.Fatal("expected the best article from techcrunch but got ", urls(articles))
+	}
+
+	articles, _ = idx.Search("bitcoin", Filter{
+		From: time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2017, 12, 17, 23, 0, 0, 0, time.UTC),
+	})
+	if len(articles) != 1 || articles[0].URL != "https://techcrunch.com/2" {
+		t.Fatal("expected only the article from the 17th but got ", urls(articles))
+	}
+}
+
+func TestFromArchive(t *testing.T) {
+	a, err := archive.Open(filepath.Join(t.TempDir(), "archive.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Ingest("everything?q=bitcoin", "en", []news.Article{
+		{Title: "Bitcoin", URL: "https://example.com/1"},
+		{Title: "Ethereum", URL: "https://example.com/2"},
+	})
+
+	idx := FromArchive(a)
+	if idx.Len() != 2 {
+		t.Fatal("expected 2 articles but got ", idx.Len())
+	}
+
+	articles, _ := idx.Search("bitcoin", Filter{Language: "en"})
+	if len(articles) != 1 {
+		t.Fatal("expected 1 article but got ", urls(articles))
+	}
+}
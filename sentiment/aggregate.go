@@ -0,0 +1,100 @@
+package sentiment
+
+import (
+	"sort"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// Scored is an article with its score.
+type Scored struct {
+	Article news.Article
+	Score   Score
+}
+
+// Articles scores every article.
+func Articles(articles []news.Article, language string) []Scored {
+	result := make([]Scored, len(articles))
+	for i, a := range articles {
+		result[i] = Scored{Article: a, Score: Article(a, language)}
+	}
+	return result
+}
+
+// Summary is the sentiment of a group of articles.
+type Summary struct {
+	Key string
+
+	Articles int
+	Positive int
+	Negative int
+	Neutral  int
+
+	// Mean is the average polarity.
+	Mean float64
+}
+
+// Summarize sums up all articles, for example the result of a query.
+func Summarize(key string, scored []Scored) Summary {
+	s := Summary{Key: key}
+	var sum float64
+	for _, a := range scored {
+		s.add(a.Score)
+		sum += a.Score.Polarity
+	}
+	if s.Articles > 0 {
+		s.Mean = sum / float64(s.Articles)
+	}
+	return s
+}
+
+func (s *Summary) add(score Score) {
+	s.Articles++
+	switch score.Label() {
+	case "positive":
+		s.Positive++
+	case "negative":
+		s.Negative++
+	default:
+		s.Neutral++
+	}
+}
+
+// GroupBy sums up the articles with the same key. The summaries are
+// sorted by key.
+func GroupBy(scored []Scored, key func(news.Article) string) []Summary {
+	groups := make(map[string][]Scored)
+	for _, a := range scored {
+		k := key(a.Article)
+		groups[k] = append(groups[k], a)
+	}
+
+	result := make([]Summary, 0, len(groups))
+	for k, group := range groups {
+		result = append(result, Summarize(k, group))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// BySource sums up the articles per source name.
+func BySource(scored []Scored) []Summary {
+	return GroupBy(scored, func(a news.Article) string {
+		return a.Source.Name
+	})
+}
+
+// ByDay sums up the articles per day of publication ("2006-01-02")
+// in UTC. Articles without a valid date have the key "".
+func ByDay(scored []Scored) []Summary {
+	return GroupBy(scored, func(a news.Article) string {
+		t, err := time.Parse(time.RFC3339, a.PublishedAt)
+		if err != nil {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02")
+	})
+}
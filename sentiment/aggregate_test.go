@@ -0,0 +1,36 @@
+package sentiment
+
+import (
+	"testing"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+func TestBySource(t *testing.T) {
+	t.Parallel()
+
+	scored := Articles([]news.Article{
+		{Title: "Great success", Source: news.ArticleSource{Name: "B"}, PublishedAt: "2024-03-01T10:00:00Z"},
+		{Title: "Terrible failure", Source: news.ArticleSource{Name: "B"}, PublishedAt: "2024-03-02T10:00:00Z"},
+		{Title: "A new office", Source: news.ArticleSource{Name: "A"}, PublishedAt: "2024-03-02T23:30:00+02:00"},
+	}, "en")
+
+	sources := BySource(scored)
+	if len(sources) != 2 || sources[0].Key != "A" || sources[1].Key != "B" {
+		t.Fatalf("unexpected summaries %+v", sources)
+	}
+	b := sources[1]
+	if b.Articles != 2 || b.Positive != 1 || b.Negative != 1 || b.Neutral != 0 {
+		t.Fatalf("unexpected summary %+v", b)
+	}
+
+	days := ByDay(scored)
+	if len(days) != 2 || days[0].Key != "2024-03-01" || days[1].Articles != 2 {
+		t.Fatalf("unexpected summaries %+v", days)
+	}
+
+	all := Summarize("example corp", scored)
+	if all.Articles != 3 || all.Neutral != 1 || all.Mean >= 0.5 || all.Mean <= -0.5 {
+		t.Fatalf("unexpected summary %+v", all)
+	}
+}
@@ -0,0 +1,140 @@
+package sentiment
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Lexicon contains the scored words of a language.
+type Lexicon struct {
+	// Words maps a word to its valence between -4 (very negative)
+	// and 4 (very positive).
+	Words map[string]float64
+
+	// Negations flip the valence of the next words, for example
+	// "not good".
+	Negations map[string]bool
+
+	// Intensifiers change the valence of the next word, for
+	// example "very" (0.3) or "slightly" (-0.3).
+	Intensifiers map[string]float64
+
+	// Suffixes are removed from unknown words before they are
+	// looked up again, so "gains" finds "gain".
+	Suffixes []string
+}
+
+// Lexicons are the embedded lexicons by language. Add one to support
+// another language.
+var Lexicons = map[string]*Lexicon{
+	"en": {
+		Words: valences(`
+			good:1.9 great:3.1 excellent:3.2 positive:2.6 success:2.7
+			successful:2.8 win:2.8 wins:2.8 won:2.7 gain:2.4 growth:2.1
+			grow:1.8 rise:1.4 rises:1.4 rising:1.4 boost:1.7 strong:2.3
+			record:1.2 best:3.2 better:1.9 improve:1.9 improved:2.0
+			profit:1.9 profitable:2.0 recovery:1.7 recover:1.6 rally:1.8
+			surge:1.5 soar:2.0 soars:2.0 breakthrough:2.7 innovative:2.0
+			praise:2.6 praised:2.6 happy:2.7 hope:1.9 optimistic:2.3
+			celebrate:2.7 celebrates:2.7 award:2.5 love:3.2 safe:1.9
+			peace:2.5 agree:1.5 agreement:1.6 support:1.7 benefit:2.0
+			help:1.7 welcome:2.0 approve:1.6 approved:1.6 stable:1.2
+			bad:-2.5 terrible:-3.1 awful:-3.1 poor:-2.1 negative:-2.3
+			fail:-2.5 fails:-2.5 failed:-2.3 failure:-2.6 loss:-1.9
+			losses:-2.0 lose:-1.9 lost:-1.6 decline:-1.6 drop:-1.1
+			drops:-1.1 fall:-1.0 falls:-1.0 plunge:-2.0 crash:-2.5
+			crisis:-3.1 risk:-1.3 weak:-1.9 worse:-2.3 worst:-3.1 cut:-1.1
+			cuts:-1.1 layoffs:-2.5 scandal:-2.7 fraud:-3.2 lawsuit:-1.6
+			sue:-1.6 sued:-1.8 fined:-1.9 warn:-1.4 warning:-1.4
+			warns:-1.4 fear:-2.2 fears:-2.2 concern:-1.5 concerns:-1.5
+			angry:-2.3 attack:-2.5 war:-2.9 death:-2.9 dead:-3.3 kill:-3.7
+			killed:-3.5 injured:-2.2 disaster:-3.1 collapse:-2.6 recall:-1.2
+			ban:-1.6 banned:-1.9 protest:-1.0 criticism:-1.9 criticized:-1.9
+			delay:-1.3 delayed:-1.3 problem:-1.7 problems:-1.7 hack:-1.8
+			breach:-2.2 bankrupt:-2.6 bankruptcy:-2.6 recession:-2.4
+			inflation:-0.8 threat:-2.4 violence:-3.1 corruption:-3.0`),
+		Negations: set(`not no never none nobody nothing neither nor
+			without cannot cant dont doesnt didnt isnt arent wasnt werent
+			wont wouldnt shouldnt couldnt hardly`),
+		Intensifiers: valences(`
+			very:0.3 extremely:0.5 really:0.3 highly:0.3 so:0.2 most:0.3
+			more:0.2 totally:0.4 completely:0.4 deeply:0.3 hugely:0.4
+			sharply:0.4 massive:0.4 huge:0.3 significantly:0.3
+			slightly:-0.3 somewhat:-0.3 barely:-0.5 marginally:-0.4
+			little:-0.3 partly:-0.3`),
+		Suffixes: []string{"ing", "ed", "es", "s", "ly"},
+	},
+	"de": {
+		Words: valences(`
+			gut:1.9 gute:1.9 guten:1.9 gutes:1.9 besser:1.9 beste:3.2
+			besten:3.2 hervorragend:3.2 toll:2.8 positiv:2.6 erfolg:2.7
+			erfolgreich:2.8 gewinn:1.9 gewinne:1.9 gewinnt:2.4 gewinnen:2.4
+			sieg:2.8 siegt:2.8 wachstum:2.1 wächst:1.8 steigt:1.4
+			steigen:1.4 anstieg:1.2 stark:2.1 starke:2.1 starken:2.1
+			rekord:1.2 verbessert:2.0 erholung:1.7 erholt:1.6 durchbruch:2.7
+			innovativ:2.0 lob:2.6 gelobt:2.6 glücklich:2.7 freude:2.8
+			hoffnung:1.9 optimistisch:2.3 feiern:2.7 feiert:2.7 preis:0.5
+			auszeichnung:2.5 liebe:3.2 sicher:1.9 frieden:2.5 einigung:1.9
+			unterstützung:1.7 hilfe:1.7 stabil:1.2 zufrieden:2.2
+			schlecht:-2.5 schlechte:-2.5 schlechter:-2.3 schlimm:-2.8
+			furchtbar:-3.1 negativ:-2.3 scheitern:-2.5 scheitert:-2.5
+			gescheitert:-2.3 verlust:-1.9 verluste:-2.0 verliert:-1.9
+			verloren:-1.6 rückgang:-1.6 sinkt:-1.1 sinken:-1.1 fällt:-1.0
+			einbruch:-2.2 absturz:-2.5 krise:-3.1 risiko:-1.3 schwach:-1.9
+			schwache:-1.9 schlechteste:-3.1 kürzungen:-1.3 stellenabbau:-2.5
+			entlassungen:-2.5 skandal:-2.7 betrug:-3.2 klage:-1.6 strafe:-1.9
+			warnt:-1.4 warnung:-1.4 angst:-2.2 sorge:-1.5 sorgen:-1.5
+			wut:-2.3 angriff:-2.5 krieg:-2.9 tod:-2.9 tot:-3.3 tote:-3.3
+			getötet:-3.5 verletzt:-2.2 katastrophe:-3.1 zusammenbruch:-2.6
+			rückruf:-1.2 verbot:-1.6 verboten:-1.9 protest:-1.0 kritik:-1.9
+			kritisiert:-1.9 verzögerung:-1.3 problem:-1.7 probleme:-1.7
+			pleite:-2.6 insolvenz:-2.6 rezession:-2.4 inflation:-0.8
+			bedrohung:-2.4 gewalt:-3.1 korruption:-3.0`),
+		Negations: set(`nicht kein keine keinen keiner keinem keines nie
+			niemals nichts niemand weder ohne kaum`),
+		Intensifiers: valences(`
+			sehr:0.3 extrem:0.5 äußerst:0.5 wirklich:0.3 besonders:0.3
+			total:0.4 völlig:0.4 deutlich:0.3 massiv:0.4 enorm:0.4
+			höchst:0.4 leicht:-0.3 etwas:-0.3 wenig:-0.3
+			teilweise:-0.3`),
+		Suffixes: []string{"en", "er", "es", "em", "e", "s", "n"},
+	},
+}
+
+// lookup returns the valence of the word or the word without one
+// of the suffixes.
+func (l *Lexicon) lookup(word string) (float64, bool) {
+	if v, ok := l.Words[word]; ok {
+		return v, true
+	}
+	for _, suffix := range l.Suffixes {
+		if stem := strings.TrimSuffix(word, suffix); stem != word && len(stem) > 2 {
+			if v, ok := l.Words[stem]; ok {
+				return v, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// valences parses a list like "good:1.9 bad:-2.5".
+func valences(list string) map[string]float64 {
+	m := make(map[string]float64)
+	for _, field := range strings.Fields(list) {
+		parts := strings.SplitN(field, ":", 2)
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			panic("sentiment: invalid valence " + field)
+		}
+		m[parts[0]] = v
+	}
+	return m
+}
+
+func set(list string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		m[w] = true
+	}
+	return m
+}
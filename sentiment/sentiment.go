@@ -0,0 +1,140 @@
+// Package sentiment scores articles as positive or negative with
+// word lists (lexicons) for English and German.
+//
+// The scoring is deterministic and works offline: every known word
+// of the title and description has a valence, a negation in the
+// three words before it flips it ("not good") and an intensifier
+// right before it makes it stronger or weaker ("very good"). The sum
+// is normalized to a polarity between -1 and 1.
+package sentiment
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// Threshold is the polarity above which a score is positive and below
+// the negative of which it is negative.
+const Threshold = 0.05
+
+const (
+	negationWindow = 3
+	negationFactor = -0.74
+
+	// alpha normalizes the sum of the valences, a sum of 4 is a
+	// polarity of about 0.72.
+	alpha = 15
+)
+
+// Score is the sentiment of a text.
+type Score struct {
+	// Polarity is between -1 (negative) and 1 (positive).
+	Polarity float64
+
+	// Positive and Negative are the number of words with a
+	// positive or negative valence (after negation).
+	Positive int
+	Negative int
+}
+
+// Label returns "positive", "negative" or "neutral".
+func (s Score) Label() string {
+	switch {
+	case s.Polarity > Threshold:
+		return "positive"
+	case s.Polarity < -Threshold:
+		return "negative"
+	default:
+		return "neutral"
+	}
+}
+
+// Text scores the text with the lexicon of the language. If the
+// language is empty or unknown, the lexicon with the most known
+// words is used.
+func Text(text, language string) Score {
+	words := tokenize(text)
+	lex := Lexicons[language]
+	if lex == nil {
+		lex = guess(words)
+	}
+	if lex == nil {
+		return Score{}
+	}
+
+	var score Score
+	var sum float64
+	for i, word := range words {
+		v, ok := lex.lookup(word)
+		if !ok {
+			continue
+		}
+
+		if i > 0 {
+			if boost, ok := lex.Intensifiers[words[i-1]]; ok {
+				v *= 1 + boost
+			}
+		}
+		for j := i - 1; j >= 0 && j >= i-negationWindow; j-- {
+			if lex.Negations[words[j]] {
+				v *= negationFactor
+				break
+			}
+		}
+
+		sum += v
+		if v > 0 {
+			score.Positive++
+		} else if v < 0 {
+			score.Negative++
+		}
+	}
+
+	score.Polarity = sum / math.Sqrt(sum*sum+alpha)
+	return score
+}
+
+// Article scores the title and the description of the article.
+func Article(a news.Article, language string) Score {
+	return Text(a.Title+". "+a.Description, language)
+}
+
+// guess returns the lexicon with the most known words. On a tie the
+// language that comes first alphabetically wins, so the result
+// doesn't depend on the order of the map.
+func guess(words []string) *Lexicon {
+	languages := make([]string, 0, len(Lexicons))
+	for lang := range Lexicons {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+
+	var best *Lexicon
+	bestHits := 0
+	for _, lang := range languages {
+		lex := Lexicons[lang]
+		hits := 0
+		for _, w := range words {
+			if _, ok := lex.Words[w]; ok || lex.Negations[w] {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = lex, hits
+		}
+	}
+	return best
+}
+
+// tokenize removes the apostrophes ("don't" becomes "dont") and
+// splits the text into lowercase words.
+func tokenize(text string) []string {
+	text = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(text))
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,85 @@
+package sentiment
+
+import (
+	"testing"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+func TestText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text     string
+		language string
+		label    string
+	}{
+		{"Example Corp reports record profit", "en", "positive"},
+		{"Example Corp shares crash after fraud scandal", "en", "negative"},
+		{"Example Corp opens a new office", "en", "neutral"},
+		{"The results were not good", "en", "negative"},
+		{"It wasn't a bad year", "en", "positive"},
+		{"Example Corp meldet Rekord und starkes Wachstum", "de", "positive"},
+		{"Die Zahlen sind nicht gut", "de", "negative"},
+		{"Keine Krise in Sicht", "de", "positive"},
+		// the language is guessed
+		{"Insolvenz nach Betrug", "", "negative"},
+	}
+	for _, test := range tests {
+		score := Text(test.text, test.language)
+		if score.Label() != test.label {
+			t.Errorf("%q: expected %s but got %s (%f)", test.text, test.label, score.Label(), score.Polarity)
+		}
+	}
+}
+
+func TestText_Intensifier(t *testing.T) {
+	t.Parallel()
+
+	good := Text("good results", "en")
+	veryGood := Text("very good results", "en")
+	slightlyGood := Text("slightly good results", "en")
+	if !(veryGood.Polarity > good.Polarity && good.Polarity > slightlyGood.Polarity) {
+		t.Fatalf("expected very > plain > slightly but got %f %f %f", veryGood.Polarity, good.Polarity, slightlyGood.Polarity)
+	}
+
+	if Text("sehr schlecht", "de").Polarity >= Text("schlecht", "de").Polarity {
+		t.Fatal("expected sehr to make it more negative")
+	}
+}
+
+func TestText_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	a := news.Article{Title: "Strong growth", Description: "But fears of a recession remain"}
+	first := Article(a, "en")
+	for i := 0; i < 10; i++ {
+		if Article(a, "en") != first {
+			t.Fatal("expected the same score every time")
+		}
+	}
+	if first.Positive != 2 || first.Negative != 2 {
+		t.Fatalf("unexpected counts %+v", first)
+	}
+	if first.Polarity <= -1 || first.Polarity >= 1 {
+		t.Fatal("polarity out of range ", first.Polarity)
+	}
+}
+
+func TestGuess_Tie(t *testing.T) {
+	t.Parallel()
+
+	// one known word of each language
+	words := tokenize("good gut")
+	for i := 0; i < 20; i++ {
+		if lex := guess(words); lex != Lexicons["de"] {
+			t.Fatal("expected the first language in alphabetical order on a tie")
+		}
+	}
+	if lex := guess(tokenize("good news, gut")); lex != Lexicons["de"] {
+		t.Fatal("expected the tie to hold with an unknown word")
+	}
+	if lex := guess(tokenize("good great gut")); lex != Lexicons["en"] {
+		t.Fatal("expected the language with more known words")
+	}
+}
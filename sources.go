@@ -32,14 +32,23 @@ type SourcesOptions struct {
 // track of the publishers available on the API, and you
 // can pipe it straight through to your users.
 func Sources(opt SourcesOptions) ([]Source, *ResponseInfo, *Exception) {
-	// the base url
-	url := "https://newsapi.org/v2/sources"
+	return defaultClient().Sources(opt)
+}
+
+// Sources is like the package level Sources but uses the
+// settings of the client.
+func (c *Client) Sources(opt SourcesOptions) ([]Source, *ResponseInfo, *Exception) {
+	if opt.APIKey == "" && c.apiKey != "" {
+		opt.APIKey = c.apiKey
+	}
 
-	if opt.APIKey == "" && APIKey != "" {
-		opt.APIKey = APIKey
+	if c.validate {
+		if err := opt.Validate(); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	res, info, err := fetch(url, opt, opt.ForceFreshData)
+	res, info, err := c.fetch("sources", opt, opt.ForceFreshData)
 
 	// the response does not contain `res.TotalResults` so
 	// I am setting it to the length of the array to
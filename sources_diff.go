@@ -0,0 +1,133 @@
+package news
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// FieldChange is a field of a source that has a different value.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// SourceChange is a source that exists in both snapshots but
+// with different fields.
+type SourceChange struct {
+	ID     string        `json:"id"`
+	Old    Source        `json:"old"`
+	New    Source        `json:"new"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// SourcesDiff contains the differences between two snapshots of
+// the sources. Every list is sorted by the id.
+type SourcesDiff struct {
+	Added   []Source       `json:"added"`
+	Removed []Source       `json:"removed"`
+	Changed []SourceChange `json:"changed"`
+}
+
+// Empty reports if the snapshots are the same.
+func (d SourcesDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffSources compares two snapshots of the sources, for example
+// the KnownSources with the result of Sources. The sources are
+// matched by their id.
+func DiffSources(old, new []Source) SourcesDiff {
+	oldByID := make(map[string]Source, len(old))
+	for _, s := range old {
+		oldByID[s.ID] = s
+	}
+	newByID := make(map[string]Source, len(new))
+	for _, s := range new {
+		newByID[s.ID] = s
+	}
+
+	var d SourcesDiff
+	for id, n := range newByID {
+		o, ok := oldByID[id]
+		if !ok {
+			d.Added = append(d.Added, n)
+			continue
+		}
+		if fields := diffFields(o, n); len(fields) > 0 {
+			d.Changed = append(d.Changed, SourceChange{
+				ID:     id,
+				Old:    o,
+				New:    n,
+				Fields: fields,
+			})
+		}
+	}
+	for id, o := range oldByID {
+		if _, ok := newByID[id]; !ok {
+			d.Removed = append(d.Removed, o)
+		}
+	}
+
+	sort.Slice(d.Added, func(i, j int) bool { return d.Added[i].ID < d.Added[j].ID })
+	sort.Slice(d.Removed, func(i, j int) bool { return d.Removed[i].ID < d.Removed[j].ID })
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].ID < d.Changed[j].ID })
+	return d
+}
+
+func diffFields(o, n Source) []FieldChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"name", o.Name, n.Name},
+		{"description", o.Description, n.Description},
+		{"url", o.URL, n.URL},
+		{"category", o.Category, n.Category},
+		{"language", o.Language, n.Language},
+		{"country", o.Country, n.Country},
+	}
+
+	var changes []FieldChange
+	for _, f := range fields {
+		if f.old != f.new {
+			changes = append(changes, FieldChange{Field: f.name, Old: f.old, New: f.new})
+		}
+	}
+	return changes
+}
+
+// WriteTo writes the differences in a readable form. Added sources
+// start with "+", removed ones with "-" and changed ones with "~"
+// followed by a line for every changed field.
+func (d SourcesDiff) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	write := func(format string, args ...interface{}) error {
+		n, err := fmt.Fprintf(w, format, args...)
+		total += int64(n)
+		return err
+	}
+
+	for _, s := range d.Added {
+		if err := write("+ %s (%s)\n", s.ID, s.Name); err != nil {
+			return total, err
+		}
+	}
+	for _, s := range d.Removed {
+		if err := write("- %s (%s)\n", s.ID, s.Name); err != nil {
+			return total, err
+		}
+	}
+	for _, c := range d.Changed {
+		if err := write("~ %s\n", c.ID); err != nil {
+			return total, err
+		}
+		for _, f := range c.Fields {
+			if err := write("    %s: %q -> %q\n", f.Field, f.Old, f.New); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,51 @@
+package news
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffSources(t *testing.T) {
+	t.Parallel()
+
+	old := []Source{
+		{ID: "a", Name: "A", Category: "general"},
+		{ID: "b", Name: "B"},
+		{ID: "c", Name: "C"},
+	}
+	new := []Source{
+		{ID: "d", Name: "D"},
+		{ID: "c", Name: "C"},
+		{ID: "a", Name: "A", Category: "business"},
+	}
+
+	d := DiffSources(old, new)
+	if d.Empty() {
+		t.Fatal("expected differences")
+	}
+	if len(d.Added) != 1 || d.Added[0].ID != "d" {
+		t.Fatalf("expected 'd' to be added but got %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].ID != "b" {
+		t.Fatalf("expected 'b' to be removed but got %+v", d.Removed)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].ID != "a" {
+		t.Fatalf("expected 'a' to be changed but got %+v", d.Changed)
+	}
+
+	fields := d.Changed[0].Fields
+	if len(fields) != 1 || fields[0] != (FieldChange{Field: "category", Old: "general", New: "business"}) {
+		t.Fatalf("unexpected field changes: %+v", fields)
+	}
+
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	expected := "+ d (D)\n- b (B)\n~ a\n    category: \"general\" -> \"business\"\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	if !DiffSources(old, old).Empty() {
+		t.Fatal("expected no differences for the same sources")
+	}
+}
@@ -0,0 +1,66 @@
+// Code generated by gensources from testdata/sources.json; DO NOT EDIT.
+
+package news
+
+var sourcesSnapshot = []Source{
+	{ID: "abc-news", Name: "ABC News", Description: "", URL: "https://abcnews.go.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "abc-news-au", Name: "ABC News (AU)", Description: "", URL: "https://www.abc.net.au/news", Category: "general", Language: "en", Country: "au"},
+	{ID: "al-jazeera-english", Name: "Al Jazeera English", Description: "", URL: "https://www.aljazeera.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "ansa", Name: "ANSA.it", Description: "", URL: "https://www.ansa.it", Category: "general", Language: "it", Country: "it"},
+	{ID: "ars-technica", Name: "Ars Technica", Description: "", URL: "https://arstechnica.com", Category: "technology", Language: "en", Country: "us"},
+	{ID: "associated-press", Name: "Associated Press", Description: "", URL: "https://apnews.com/", Category: "general", Language: "en", Country: "us"},
+	{ID: "axios", Name: "Axios", Description: "", URL: "https://www.axios.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "bbc-news", Name: "BBC News", Description: "", URL: "https://www.bbc.co.uk/news", Category: "general", Language: "en", Country: "gb"},
+	{ID: "bbc-sport", Name: "BBC Sport", Description: "", URL: "https://www.bbc.co.uk/sport", Category: "sports", Language: "en", Country: "gb"},
+	{ID: "bild", Name: "Bild", Description: "", URL: "https://www.bild.de", Category: "general", Language: "de", Country: "de"},
+	{ID: "bloomberg", Name: "Bloomberg", Description: "", URL: "https://www.bloomberg.com", Category: "business", Language: "en", Country: "us"},
+	{ID: "business-insider", Name: "Business Insider", Description: "", URL: "https://www.businessinsider.com", Category: "business", Language: "en", Country: "us"},
+	{ID: "cbc-news", Name: "CBC News", Description: "", URL: "https://www.cbc.ca/news", Category: "general", Language: "en", Country: "ca"},
+	{ID: "cbs-news", Name: "CBS News", Description: "", URL: "https://www.cbsnews.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "cnn", Name: "CNN", Description: "", URL: "https://www.cnn.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "der-tagesspiegel", Name: "Der Tagesspiegel", Description: "", URL: "https://www.tagesspiegel.de", Category: "general", Language: "de", Country: "de"},
+	{ID: "die-zeit", Name: "Die Zeit", Description: "", URL: "https://www.zeit.de/index", Category: "business", Language: "de", Country: "de"},
+	{ID: "el-mundo", Name: "El Mundo", Description: "", URL: "https://www.elmundo.es", Category: "general", Language: "es", Country: "es"},
+	{ID: "engadget", Name: "Engadget", Description: "", URL: "https://www.engadget.com", Category: "technology", Language: "en", Country: "us"},
+	{ID: "espn", Name: "ESPN", Description: "", URL: "https://espn.go.com", Category: "sports", Language: "en", Country: "us"},
+	{ID: "financial-post", Name: "Financial Post", Description: "", URL: "https://business.financialpost.com", Category: "business", Language: "en", Country: "ca"},
+	{ID: "focus", Name: "Focus", Description: "", URL: "https://www.focus.de", Category: "general", Language: "de", Country: "de"},
+	{ID: "fortune", Name: "Fortune", Description: "", URL: "https://fortune.com", Category: "business", Language: "en", Country: "us"},
+	{ID: "four-four-two", Name: "FourFourTwo", Description: "", URL: "https://www.fourfourtwo.com/news", Category: "sports", Language: "en", Country: "gb"},
+	{ID: "fox-news", Name: "Fox News", Description: "", URL: "https://www.foxnews.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "gruenderszene", Name: "Gruenderszene", Description: "", URL: "https://www.gruenderszene.de", Category: "technology", Language: "de", Country: "de"},
+	{ID: "hacker-news", Name: "Hacker News", Description: "", URL: "https://news.ycombinator.com", Category: "technology", Language: "en", Country: "us"},
+	{ID: "handelsblatt", Name: "Handelsblatt", Description: "", URL: "https://www.handelsblatt.com", Category: "business", Language: "de", Country: "de"},
+	{ID: "ign", Name: "IGN", Description: "", URL: "https://www.ign.com", Category: "entertainment", Language: "en", Country: "us"},
+	{ID: "independent", Name: "Independent", Description: "", URL: "https://www.independent.co.uk", Category: "general", Language: "en", Country: "gb"},
+	{ID: "la-repubblica", Name: "La Repubblica", Description: "", URL: "https://www.repubblica.it", Category: "general", Language: "it", Country: "it"},
+	{ID: "le-monde", Name: "Le Monde", Description: "", URL: "https://www.lemonde.fr", Category: "general", Language: "fr", Country: "fr"},
+	{ID: "lequipe", Name: "L'equipe", Description: "", URL: "https://www.lequipe.fr", Category: "sports", Language: "fr", Country: "fr"},
+	{ID: "marca", Name: "Marca", Description: "", URL: "https://www.marca.com", Category: "sports", Language: "es", Country: "es"},
+	{ID: "mashable", Name: "Mashable", Description: "", URL: "https://mashable.com", Category: "entertainment", Language: "en", Country: "us"},
+	{ID: "medical-news-today", Name: "Medical News Today", Description: "", URL: "https://www.medicalnewstoday.com", Category: "health", Language: "en", Country: "us"},
+	{ID: "national-geographic", Name: "National Geographic", Description: "", URL: "https://news.nationalgeographic.com", Category: "science", Language: "en", Country: "us"},
+	{ID: "nbc-news", Name: "NBC News", Description: "", URL: "https://www.nbcnews.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "new-scientist", Name: "New Scientist", Description: "", URL: "https://www.newscientist.com/section/news", Category: "science", Language: "en", Country: "us"},
+	{ID: "newsweek", Name: "Newsweek", Description: "", URL: "https://www.newsweek.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "nfl-news", Name: "NFL News", Description: "", URL: "https://www.nfl.com/news", Category: "sports", Language: "en", Country: "us"},
+	{ID: "politico", Name: "Politico", Description: "", URL: "https://www.politico.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "polygon", Name: "Polygon", Description: "", URL: "https://www.polygon.com", Category: "entertainment", Language: "en", Country: "us"},
+	{ID: "reuters", Name: "Reuters", Description: "", URL: "https://www.reuters.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "spiegel-online", Name: "Spiegel Online", Description: "", URL: "https://www.spiegel.de", Category: "general", Language: "de", Country: "de"},
+	{ID: "t3n", Name: "T3n", Description: "", URL: "https://t3n.de", Category: "technology", Language: "de", Country: "de"},
+	{ID: "talksport", Name: "TalkSport", Description: "", URL: "https://talksport.com", Category: "sports", Language: "en", Country: "gb"},
+	{ID: "techcrunch", Name: "TechCrunch", Description: "", URL: "https://techcrunch.com", Category: "technology", Language: "en", Country: "us"},
+	{ID: "techradar", Name: "TechRadar", Description: "", URL: "https://www.techradar.com", Category: "technology", Language: "en", Country: "us"},
+	{ID: "the-hill", Name: "The Hill", Description: "", URL: "https://thehill.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "the-huffington-post", Name: "The Huffington Post", Description: "", URL: "https://www.huffingtonpost.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "the-next-web", Name: "The Next Web", Description: "", URL: "https://thenextweb.com", Category: "technology", Language: "en", Country: "us"},
+	{ID: "the-verge", Name: "The Verge", Description: "", URL: "https://www.theverge.com", Category: "technology", Language: "en", Country: "us"},
+	{ID: "the-wall-street-journal", Name: "The Wall Street Journal", Description: "", URL: "https://www.wsj.com", Category: "business", Language: "en", Country: "us"},
+	{ID: "the-washington-post", Name: "The Washington Post", Description: "", URL: "https://www.washingtonpost.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "time", Name: "Time", Description: "", URL: "https://time.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "usa-today", Name: "USA Today", Description: "", URL: "https://www.usatoday.com/news", Category: "general", Language: "en", Country: "us"},
+	{ID: "vice-news", Name: "Vice News", Description: "", URL: "https://news.vice.com", Category: "general", Language: "en", Country: "us"},
+	{ID: "wired", Name: "Wired", Description: "", URL: "https://www.wired.com", Category: "technology", Language: "en", Country: "us"},
+	{ID: "wired-de", Name: "Wired.de", Description: "", URL: "https://www.wired.de", Category: "technology", Language: "de", Country: "de"},
+}
@@ -8,58 +8,72 @@ import (
 )
 
 func TestSources_WithCache(t *testing.T) {
-	testCacheHeader(t, false, func() *Exception {
+	t.Parallel()
+
+	testCacheHeader(t, false, func(c *Client) *Exception {
 		opt := SourcesOptions{}
-		_, _, err := Sources(opt)
+		_, _, err := c.Sources(opt)
 		return err
 	})
 }
 func TestSources_WithoutCache(t *testing.T) {
-	testCacheHeader(t, true, func() *Exception {
+	t.Parallel()
+
+	testCacheHeader(t, true, func(c *Client) *Exception {
 		opt := SourcesOptions{
 			ForceFreshData: true,
 		}
-		_, _, err := Sources(opt)
+		_, _, err := c.Sources(opt)
 		return err
 	})
 }
 func TestSources_LocalAPIKey(t *testing.T) {
-	testAPIKey(t, "abc", func() *Exception {
+	t.Parallel()
+
+	testAPIKey(t, Config{APIKey: "xyz"}, "abc", func(c *Client) *Exception {
 		opt := SourcesOptions{
 			APIKey: "abc",
 		}
-		_, _, err := Sources(opt)
+		_, _, err := c.Sources(opt)
 		return err
 	})
 }
-func TestSources_GlobalAPIKey(t *testing.T) {
-	APIKey = "abc"
+func TestSources_ClientAPIKey(t *testing.T) {
+	t.Parallel()
 
-	testAPIKey(t, "abc", func() *Exception {
+	testAPIKey(t, Config{APIKey: "abc"}, "abc", func(c *Client) *Exception {
 		opt := SourcesOptions{}
-		_, _, err := Sources(opt)
+		_, _, err := c.Sources(opt)
 		return err
 	})
 }
 func TestSources_Headers(t *testing.T) {
-	Headers = map[string]string{
-		"key":   "value",
-		"key-2": "value-2",
+	t.Parallel()
+
+	cfg := Config{
+		Headers: map[string]string{
+			"key":   "value",
+			"key-2": "value-2",
+		},
 	}
 
 	// with uppercase keys
 	h := map[string]string{
 		"Key":   "value",
 		"Key-2": "value-2",
+
+		"Accept-Encoding": "gzip, deflate",
 	}
-	testHeaders(t, h, func() *Exception {
+	testHeaders(t, cfg, h, func(c *Client) *Exception {
 		opt := SourcesOptions{}
-		_, _, err := Sources(opt)
+		_, _, err := c.Sources(opt)
 		return err
 	})
 }
 
 func TestSources_Sources(t *testing.T) {
+	t.Parallel()
+
 	json := `
 	{
 		"status":"ok",
@@ -86,17 +100,15 @@ func TestSources_Sources(t *testing.T) {
 	}
 	`
 
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			return &http.Response{
-				StatusCode: http.StatusOK,
-				Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
-			}, nil
-		},
-	}
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
 
 	opt := SourcesOptions{}
-	sources, info, err := Sources(opt)
+	sources, info, err := c.Sources(opt)
 
 	if err != nil {
 		t.Fatal(err)
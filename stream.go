@@ -0,0 +1,241 @@
+package news
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// callbackError is an error returned by the callback of a
+// streaming request. It is not retried.
+type callbackError struct {
+	err error
+}
+
+func (e callbackError) Error() string {
+	return e.err.Error()
+}
+
+// streamDecoder returns a decoder that passes the elements of the
+// `articles` and `sources` arrays to the callbacks one at a time
+// instead of keeping the whole array in memory. The elements are
+// only passed on once the status is known to be "ok". If the api
+// sends the array before the status it is buffered.
+func streamDecoder(onArticle func(Article) error, onSource func(Source) error) decoder {
+	article := func(dec *json.Decoder) error {
+		var a Article
+		if err := dec.Decode(&a); err != nil {
+			return err
+		}
+		return callback(onArticle == nil, func() error { return onArticle(a) })
+	}
+	source := func(dec *json.Decoder) error {
+		var s Source
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		return callback(onSource == nil, func() error { return onSource(s) })
+	}
+
+	return func(body io.Reader, res *networkResult) error {
+		dec := json.NewDecoder(body)
+
+		// arrays that came before the status
+		type pending struct {
+			raw  json.RawMessage
+			next func(*json.Decoder) error
+		}
+		var later []pending
+
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := t.(string)
+
+			var next func(*json.Decoder) error
+			switch key {
+			case "status":
+				err = dec.Decode(&res.Status)
+			case "code":
+				err = dec.Decode(&res.Code)
+			case "message":
+				err = dec.Decode(&res.Message)
+			case "totalResults":
+				err = dec.Decode(&res.TotalResults)
+			case "articles":
+				next = article
+			case "sources":
+				next = source
+			default:
+				var skip json.RawMessage
+				err = dec.Decode(&skip)
+			}
+			if err != nil {
+				return err
+			}
+			if next == nil {
+				continue
+			}
+
+			switch res.Status {
+			case "ok":
+				err = streamArray(dec, res, next)
+			case "":
+				var raw json.RawMessage
+				err = dec.Decode(&raw)
+				later = append(later, pending{raw, next})
+			default:
+				// an error response, the elements are not needed
+				var skip json.RawMessage
+				err = dec.Decode(&skip)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+
+		if res.Status != "ok" {
+			return nil
+		}
+		for _, p := range later {
+			if err := streamArray(json.NewDecoder(bytes.NewReader(p.raw)), res, p.next); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func streamArray(dec *json.Decoder, res *networkResult, next func(*json.Decoder) error) error {
+	// the array can also be null
+	t, err := dec.Token()
+	if err != nil || t == nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("expected an array but got %v", t)
+	}
+
+	for dec.More() {
+		if err := next(dec); err != nil {
+			return err
+		}
+		res.streamed++
+	}
+	return expectDelim(dec, ']')
+}
+
+// callback calls fn unless skip is true. The error of fn is
+// wrapped, so that it is not mistaken for a decoding error.
+func callback(skip bool, fn func() error) error {
+	if skip {
+		return nil
+	}
+	if err := fn(); err != nil {
+		return callbackError{err}
+	}
+	return nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %v but got %v", delim, t)
+	}
+	return nil
+}
+
+// TopHeadlinesEach is like TopHeadlines but passes the articles to
+// fn one at a time while the response is read. This keeps the memory
+// constant for large pages. If fn returns an error the request is
+// abandoned. The ArticlesHook is not called.
+func TopHeadlinesEach(opt TopHeadlinesOptions, fn func(Article) error) (*ResponseInfo, *Exception) {
+	return defaultClient().TopHeadlinesEach(opt, fn)
+}
+
+// TopHeadlinesEach is like the package level TopHeadlinesEach but
+// uses the settings of the client.
+func (c *Client) TopHeadlinesEach(opt TopHeadlinesOptions, fn func(Article) error) (*ResponseInfo, *Exception) {
+	if opt.APIKey == "" && c.apiKey != "" {
+		opt.APIKey = c.apiKey
+	}
+
+	if c.validate {
+		if err := opt.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	res, info, err := c.request("top-headlines", opt, opt.ForceFreshData, streamDecoder(fn, nil))
+	if info != nil {
+		info.TotalResults = res.TotalResults
+	}
+	return info, err
+}
+
+// EverythingEach is like Everything but passes the articles to
+// fn one at a time while the response is read. This keeps the memory
+// constant for large pages. If fn returns an error the request is
+// abandoned. The ArticlesHook is not called.
+func EverythingEach(opt EverythingOptions, fn func(Article) error) (*ResponseInfo, *Exception) {
+	return defaultClient().EverythingEach(opt, fn)
+}
+
+// EverythingEach is like the package level EverythingEach but
+// uses the settings of the client.
+func (c *Client) EverythingEach(opt EverythingOptions, fn func(Article) error) (*ResponseInfo, *Exception) {
+	if opt.APIKey == "" && c.apiKey != "" {
+		opt.APIKey = c.apiKey
+	}
+
+	if c.validate {
+		if err := opt.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	res, info, err := c.request("everything", opt, opt.ForceFreshData, streamDecoder(fn, nil))
+	if info != nil {
+		info.TotalResults = res.TotalResults
+	}
+	return info, err
+}
+
+// SourcesEach is like Sources but passes the sources to fn one
+// at a time while the response is read. If fn returns an error
+// the request is abandoned.
+func SourcesEach(opt SourcesOptions, fn func(Source) error) (*ResponseInfo, *Exception) {
+	return defaultClient().SourcesEach(opt, fn)
+}
+
+// SourcesEach is like the package level SourcesEach but uses the
+// settings of the client.
+func (c *Client) SourcesEach(opt SourcesOptions, fn func(Source) error) (*ResponseInfo, *Exception) {
+	if opt.APIKey == "" && c.apiKey != "" {
+		opt.APIKey = c.apiKey
+	}
+
+	if c.validate {
+		if err := opt.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	res, info, err := c.request("sources", opt, opt.ForceFreshData, streamDecoder(nil, fn))
+	if info != nil {
+		info.TotalResults = res.streamed
+	}
+	return info, err
+}
@@ -0,0 +1,169 @@
+package news
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func jsonClient(cfg Config, json string) *Client {
+	return mockClient(cfg, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
+}
+
+func TestEverythingEach(t *testing.T) {
+	t.Parallel()
+
+	c := jsonClient(Config{}, `{"status":"ok","totalResults":3,"unknown":{"a":[1,2]},"articles":[
+		{"title":"a","source":{"id":"x","name":"X"}},{"title":"b"},{"title":"c"}
+	]}`)
+
+	var titles []string
+	info, err := c.EverythingEach(EverythingOptions{}, func(a Article) error {
+		titles = append(titles, a.Title)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(titles, ",") != "a,b,c" {
+		t.Fatal("expected the articles in order but got ", titles)
+	}
+	if info.TotalResults != 3 {
+		t.Fatal("expected 3 total results but got ", info.TotalResults)
+	}
+}
+
+func TestEverythingEach_CallbackError(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	c := mockClient(Config{Retry: RetryPolicy{MaxRetries: 3}}, func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"ok","articles":[{"title":"a"},{"title":"b"}]}`)),
+		}, nil
+	})
+
+	var count int
+	_, err := c.EverythingEach(EverythingOptions{}, func(a Article) error {
+		count++
+		return errors.New("stop")
+	})
+	if err == nil || err.Code != "[callback]" || err.Message != "stop" {
+		t.Fatal("expected the error of the callback but got ", err)
+	}
+	if count != 1 || requests != 1 {
+		t.Fatalf("expected to stop after the first article (count %d, requests %d)", count, requests)
+	}
+}
+
+func TestTopHeadlinesEach_APIError(t *testing.T) {
+	t.Parallel()
+
+	c := jsonClient(Config{}, `{"status":"error","code":"apiKeyInvalid","message":"invalid key"}`)
+
+	_, err := c.TopHeadlinesEach(TopHeadlinesOptions{}, func(a Article) error {
+		t.Fatal("did not expect an article")
+		return nil
+	})
+	if err == nil || err.Code != "apiKeyInvalid" {
+		t.Fatal("expected the error of the api but got ", err)
+	}
+}
+
+func TestEverythingEach_StatusLast(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		json   string
+		titles string
+		code   string
+	}{
+		{`{"articles":[{"title":"a"},{"title":"b"}],"status":"ok","totalResults":2}`, "a,b", ""},
+		{`{"articles":[{"title":"a"}],"status":"error","code":"rateLimited"}`, "", "rateLimited"},
+		{`{"status":"error","code":"rateLimited","articles":[{"title":"a"}]}`, "", "rateLimited"},
+	}
+	for _, test := range tests {
+		c := jsonClient(Config{}, test.json)
+
+		var titles []string
+		_, err := c.EverythingEach(EverythingOptions{}, func(a Article) error {
+			titles = append(titles, a.Title)
+			return nil
+		})
+		if (err == nil && test.code != "") || (err != nil && err.Code != test.code) {
+			t.Errorf("expected %q for %s but got %v", test.code, test.json, err)
+		}
+		if strings.Join(titles, ",") != test.titles {
+			t.Errorf("expected %q for %s but got %v", test.titles, test.json, titles)
+		}
+	}
+}
+
+func TestEach_ValidateOptions(t *testing.T) {
+	t.Parallel()
+
+	c := mockClient(Config{ValidateOptions: true}, func(req *http.Request) (*http.Response, error) {
+		t.Fatal("did not expect a request")
+		return nil, nil
+	})
+
+	_, err := c.EverythingEach(EverythingOptions{}, func(Article) error { return nil })
+	if err == nil || err.Code != "parametersMissing" {
+		t.Fatal("expected the missing query to be rejected but got ", err)
+	}
+	_, err = c.SourcesEach(SourcesOptions{Category: "cooking"}, func(Source) error { return nil })
+	if err == nil || err.Code != "parameterInvalid" {
+		t.Fatal("expected the unknown category to be rejected but got ", err)
+	}
+}
+
+func TestSourcesEach(t *testing.T) {
+	t.Parallel()
+
+	c := jsonClient(Config{}, `{"status":"ok","sources":[{"id":"a"},{"id":"b"}]}`)
+
+	var ids []string
+	info, err := c.SourcesEach(SourcesOptions{}, func(s Source) error {
+		ids = append(ids, s.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ids, ",") != "a,b" || info.TotalResults != 2 {
+		t.Fatal("expected both sources but got ", ids)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	json := `{"status":"ok","articles":[{"title":"` + strings.Repeat("a", 1000) + `"}]}`
+
+	_, _, err := jsonClient(Config{MaxBodySize: 100}, json).Everything(EverythingOptions{})
+	if err == nil || !strings.Contains(err.Message, errBodyTooLarge.Error()) {
+		t.Fatal("expected the body to be too large but got ", err)
+	}
+
+	_, err = jsonClient(Config{MaxBodySize: 100}, json).EverythingEach(EverythingOptions{}, func(Article) error {
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Message, errBodyTooLarge.Error()) {
+		t.Fatal("expected the body to be too large but got ", err)
+	}
+
+	_, _, err = jsonClient(Config{MaxBodySize: int64(len(json))}, json).Everything(EverythingOptions{})
+	if err != nil {
+		t.Fatal("expected a body with exactly the maximum size to work but got ", err)
+	}
+}
@@ -17,6 +17,9 @@ type Article struct {
 	URL         string        `json:"url"`
 	URLToImage  string        `json:"urlToImage"`
 	PublishedAt string        `json:"publishedAt"`
+
+	// Content is a truncated snippet of the article text.
+	Content string `json:"content"`
 }
 
 // TopHeadlinesOptions contains the options that can be passed
@@ -45,7 +48,19 @@ type TopHeadlinesOptions struct {
 	// The 2-letter ISO 3166-1 code of the country you want to get headlines for.
 	Country string `url:"country,omitempty"`
 
+	// Use this to page through the results if the total results
+	// found is greater than the page size.
+	Page int `url:"page,omitempty"`
+
+	// The number of results per page (20 by default, 100 is the maximum).
+	PageSize int `url:"pageSize,omitempty"`
+
 	APIKey string `url:"apiKey"`
+
+	// AllowUnknownSources lets Validate accept sources that are
+	// not in the compiled snapshot, for example sources that were
+	// added to the api after it. Default: false
+	AllowUnknownSources bool `url:"-"`
 }
 
 // TopHeadlines provides up to 10 live top and breaking headlines for
@@ -56,14 +71,23 @@ type TopHeadlinesOptions struct {
 // 		This endpoint is great for retrieving headlines for display
 // 		on news tickers or similar.
 func TopHeadlines(opt TopHeadlinesOptions) ([]Article, *ResponseInfo, *Exception) {
-	// the base url
-	url := "https://newsapi.org/v2/top-headlines"
+	return defaultClient().TopHeadlines(opt)
+}
+
+// TopHeadlines is like the package level TopHeadlines but uses the
+// settings of the client.
+func (c *Client) TopHeadlines(opt TopHeadlinesOptions) ([]Article, *ResponseInfo, *Exception) {
+	if opt.APIKey == "" && c.apiKey != "" {
+		opt.APIKey = c.apiKey
+	}
 
-	if opt.APIKey == "" && APIKey != "" {
-		opt.APIKey = APIKey
+	if c.validate {
+		if err := opt.Validate(); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	res, info, err := fetch(url, opt, opt.ForceFreshData)
+	res, info, err := c.fetch("top-headlines", opt, opt.ForceFreshData)
 	if info != nil {
 		info.TotalResults = res.TotalResults
 	}
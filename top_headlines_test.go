@@ -21,21 +21,25 @@ func (c *ClientMock) Do(req *http.Request) (*http.Response, error) {
 	return &http.Response{}, nil
 }
 
-func testCacheHeader(t *testing.T, expected bool, callback func() *Exception) {
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			included := req.Header.Get("X-No-Cache") == "true"
-			if included != expected {
-				t.Fatal("without 'ForceFreshData' the header 'X-No-Cache' should not be added OR the other way around")
-				// t.Fatal("with 'ForceFreshData' the header 'X-No-Cache' should not be missing")
-			}
-			return &http.Response{
-				StatusCode: http.StatusOK,
-			}, nil
-		},
-	}
+// mockClient creates a client that sends every request to the mock.
+func mockClient(cfg Config, do func(req *http.Request) (*http.Response, error)) *Client {
+	cfg.HTTPClient = &ClientMock{DoFunc: do}
+	return NewClient(cfg)
+}
 
-	err := callback()
+func testCacheHeader(t *testing.T, expected bool, callback func(c *Client) *Exception) {
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		included := req.Header.Get("X-No-Cache") == "true"
+		if included != expected {
+			t.Fatal("without 'ForceFreshData' the header 'X-No-Cache' should not be added OR the other way around")
+			// t.Fatal("with 'ForceFreshData' the header 'X-No-Cache' should not be missing")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+		}, nil
+	})
+
+	err := callback(c)
 
 	if err == nil {
 		t.Fatal("expected an error")
@@ -44,21 +48,19 @@ func testCacheHeader(t *testing.T, expected bool, callback func() *Exception) {
 		t.Fatal("expected the error to be about the missing body")
 	}
 }
-func testAPIKey(t *testing.T, expected string, callback func() *Exception) {
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			key := req.URL.Query().Get("apiKey")
-			if key != expected {
-				t.Fatal("expected a different api key: ", key, " != ", expected)
-			}
+func testAPIKey(t *testing.T, cfg Config, expected string, callback func(c *Client) *Exception) {
+	c := mockClient(cfg, func(req *http.Request) (*http.Response, error) {
+		key := req.URL.Query().Get("apiKey")
+		if key != expected {
+			t.Fatal("expected a different api key: ", key, " != ", expected)
+		}
 
-			return &http.Response{
-				StatusCode: http.StatusOK,
-			}, nil
-		},
-	}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+		}, nil
+	})
 
-	err := callback()
+	err := callback(c)
 
 	if err == nil {
 		t.Fatal("expected an error")
@@ -67,25 +69,23 @@ func testAPIKey(t *testing.T, expected string, callback func() *Exception) {
 		t.Fatal("expected the error to be about the missing body")
 	}
 }
-func testHeaders(t *testing.T, expected map[string]string, callback func() *Exception) {
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			headers := make(map[string]string)
-			for key := range req.Header {
-				headers[key] = req.Header.Get(key)
-			}
+func testHeaders(t *testing.T, cfg Config, expected map[string]string, callback func(c *Client) *Exception) {
+	c := mockClient(cfg, func(req *http.Request) (*http.Response, error) {
+		headers := make(map[string]string)
+		for key := range req.Header {
+			headers[key] = req.Header.Get(key)
+		}
 
-			if !reflect.DeepEqual(headers, expected) {
-				t.Fatal("the headers on the request are not the same")
-			}
+		if !reflect.DeepEqual(headers, expected) {
+			t.Fatal("the headers on the request are not the same")
+		}
 
-			return &http.Response{
-				StatusCode: http.StatusOK,
-			}, nil
-		},
-	}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+		}, nil
+	})
 
-	err := callback()
+	err := callback(c)
 
 	if err == nil {
 		t.Fatal("expected an error")
@@ -99,57 +99,71 @@ func testHeaders(t *testing.T, expected map[string]string, callback func() *Exce
 // Body:       ioutil.NopCloser(bytes.NewBufferString("Hello World")),
 
 func TestTopHeadlines_WithCache(t *testing.T) {
-	testCacheHeader(t, false, func() *Exception {
+	t.Parallel()
+
+	testCacheHeader(t, false, func(c *Client) *Exception {
 		opt := TopHeadlinesOptions{}
-		_, _, err := TopHeadlines(opt)
+		_, _, err := c.TopHeadlines(opt)
 		return err
 	})
 }
 func TestTopHeadlines_WithoutCache(t *testing.T) {
-	testCacheHeader(t, true, func() *Exception {
+	t.Parallel()
+
+	testCacheHeader(t, true, func(c *Client) *Exception {
 		opt := TopHeadlinesOptions{
 			ForceFreshData: true,
 		}
-		_, _, err := TopHeadlines(opt)
+		_, _, err := c.TopHeadlines(opt)
 		return err
 	})
 }
 func TestTopHeadlines_LocalAPIKey(t *testing.T) {
-	testAPIKey(t, "abc", func() *Exception {
+	t.Parallel()
+
+	testAPIKey(t, Config{APIKey: "xyz"}, "abc", func(c *Client) *Exception {
 		opt := TopHeadlinesOptions{
 			APIKey: "abc",
 		}
-		_, _, err := TopHeadlines(opt)
+		_, _, err := c.TopHeadlines(opt)
 		return err
 	})
 }
-func TestTopHeadlines_GlobalAPIKey(t *testing.T) {
-	APIKey = "abc"
+func TestTopHeadlines_ClientAPIKey(t *testing.T) {
+	t.Parallel()
 
-	testAPIKey(t, "abc", func() *Exception {
+	testAPIKey(t, Config{APIKey: "abc"}, "abc", func(c *Client) *Exception {
 		opt := TopHeadlinesOptions{}
-		_, _, err := TopHeadlines(opt)
+		_, _, err := c.TopHeadlines(opt)
 		return err
 	})
 }
 func TestTopHeadlines_Headers(t *testing.T) {
-	Headers = map[string]string{
-		"key":   "value",
-		"key-2": "value-2",
+	t.Parallel()
+
+	cfg := Config{
+		Headers: map[string]string{
+			"key":   "value",
+			"key-2": "value-2",
+		},
 	}
 
 	// with uppercase keys
 	h := map[string]string{
 		"Key":   "value",
 		"Key-2": "value-2",
+
+		"Accept-Encoding": "gzip, deflate",
 	}
-	testHeaders(t, h, func() *Exception {
+	testHeaders(t, cfg, h, func(c *Client) *Exception {
 		opt := TopHeadlinesOptions{}
-		_, _, err := TopHeadlines(opt)
+		_, _, err := c.TopHeadlines(opt)
 		return err
 	})
 }
 func TestTopHeadlines_Articles(t *testing.T) {
+	t.Parallel()
+
 	json := `
 	{
 		"status":"ok",
@@ -183,17 +197,15 @@ func TestTopHeadlines_Articles(t *testing.T) {
 	}
 	`
 
-	HTTPClient = &ClientMock{
-		DoFunc: func(req *http.Request) (*http.Response, error) {
-			return &http.Response{
-				StatusCode: http.StatusOK,
-				Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
-			}, nil
-		},
-	}
+	c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
 
 	opt := TopHeadlinesOptions{}
-	articles, info, err := TopHeadlines(opt)
+	articles, info, err := c.TopHeadlines(opt)
 
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,96 @@
+package news
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Tracer starts a span for every call of an endpoint (including
+// its retries). It is small enough to be implemented on top of
+// OpenTelemetry or any other tracing library.
+type Tracer interface {
+	// Start starts a span as a child of the span in the context
+	// and returns a context that contains the new span. The
+	// context is used for the http request.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a running span of a Tracer.
+type Span interface {
+	// SetAttribute is called with a string, int or bool value.
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+// RequestTracer is the tracer used for every request.
+// Default: nil (no tracing)
+var RequestTracer Tracer
+
+// The attributes that are set on every span.
+const (
+	AttrEndpoint     = "newsapi.endpoint"
+	AttrQuery        = "newsapi.query"
+	AttrPage         = "newsapi.page"
+	AttrTotalResults = "newsapi.total_results"
+	AttrCached       = "newsapi.cached"
+	AttrAttempts     = "newsapi.attempts"
+	AttrErrorCode    = "newsapi.error_code"
+)
+
+// WithContext returns a copy of the client that sends the requests
+// with the context. The context can cancel the requests and carries
+// the parent span for the Tracer.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	cp := *c
+	cp.ctx = ctx
+	return &cp
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// startSpan starts the span of a request. The query must not
+// contain the api key anymore.
+func (c *Client) startSpan(endpoint string, query url.Values) (context.Context, Span) {
+	ctx := c.context()
+	if c.tracer == nil {
+		return ctx, nil
+	}
+
+	ctx, span := c.tracer.Start(ctx, "newsapi "+endpoint)
+	span.SetAttribute(AttrEndpoint, endpoint)
+	span.SetAttribute(AttrQuery, query.Encode())
+	if page, err := strconv.Atoi(query.Get("page")); err == nil {
+		span.SetAttribute(AttrPage, page)
+	}
+	return ctx, span
+}
+
+// endSpan sets the attributes of the result and ends the span.
+func endSpan(span Span, res networkResult, cached bool, attempts int, exc *Exception) {
+	if span == nil {
+		return
+	}
+
+	span.SetAttribute(AttrAttempts, attempts)
+	if exc != nil {
+		span.SetAttribute(AttrErrorCode, exc.Code)
+	} else {
+		span.SetAttribute(AttrCached, cached)
+		// the sources endpoint has no total results
+		total := res.TotalResults
+		if len(res.Sources) > total {
+			total = len(res.Sources)
+		}
+		if res.streamed > total {
+			total = res.streamed
+		}
+		span.SetAttribute(AttrTotalResults, total)
+	}
+	span.End()
+}
@@ -0,0 +1,171 @@
+package news
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type spanKey struct{}
+
+// memoryTracer keeps the finished spans in memory.
+type memoryTracer struct {
+	mu    sync.Mutex
+	spans []*memorySpan
+}
+
+type memorySpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	ended  bool
+}
+
+func (t *memoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+	span := &memorySpan{name: name, parent: parent, attrs: make(map[string]interface{})}
+
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, name), span
+}
+
+func (s *memorySpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *memorySpan) End()                                       { s.ended = true }
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	tracer := &memoryTracer{}
+	calls := 0
+	c := mockClient(Config{
+		APIKey: "secret",
+		Tracer: tracer,
+		Retry:  RetryPolicy{MaxRetries: 2},
+	}, func(req *http.Request) (*http.Response, error) {
+		if span, _ := req.Context().Value(spanKey{}).(string); span != "newsapi everything" {
+			t.Error("expected the request to carry the span but got ", span)
+		}
+
+		calls++
+		json := `{"status":"ok","totalResults":42,"articles":[]}`
+		if calls == 1 {
+			json = `{"status":"error","code":"rateLimited"}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"X-Cached-Result": []string{"true"}},
+			Body:       ioutil.NopCloser(bytes.NewBufferString(json)),
+		}, nil
+	})
+	c.sleep = func(context.Context, time.Duration) error { return nil }
+
+	ctx := context.WithValue(context.Background(), spanKey{}, "incoming request")
+	if _, _, err := c.WithContext(ctx).Everything(EverythingOptions{Query: "bitcoin", Page: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatal("expected one span for the request and its retry but got ", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if !span.ended || span.parent != "incoming request" {
+		t.Fatalf("expected an ended child span but got %+v", span)
+	}
+
+	expected := map[string]interface{}{
+		AttrEndpoint:     "everything",
+		AttrQuery:        "from=&language=&page=2&q=bitcoin&sortBy=&to=",
+		AttrPage:         2,
+		AttrTotalResults: 42,
+		AttrCached:       true,
+		AttrAttempts:     2,
+	}
+	for key, value := range expected {
+		if span.attrs[key] != value {
+			t.Errorf("expected %s to be %v but got %v", key, value, span.attrs[key])
+		}
+	}
+}
+
+func TestTracer_Error(t *testing.T) {
+	t.Parallel()
+
+	tracer := &memoryTracer{}
+	c := mockClient(Config{Tracer: tracer}, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"error","code":"apiKeyMissing"}`)),
+		}, nil
+	})
+	c.Sources(SourcesOptions{})
+
+	if code := tracer.spans[0].attrs[AttrErrorCode]; code != "apiKeyMissing" {
+		t.Fatal("expected the error code on the span but got ", code)
+	}
+}
+
+func TestWithContext_Cancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var requests int
+	c := mockClient(Config{Retry: RetryPolicy{MaxRetries: 3}}, func(req *http.Request) (*http.Response, error) {
+		requests++
+		cancel()
+		return nil, req.Context().Err()
+	})
+	c.sleep = func(context.Context, time.Duration) error {
+		t.Fatal("did not expect a backoff after the cancel")
+		return nil
+	}
+
+	_, _, err := c.WithContext(ctx).TopHeadlines(TopHeadlinesOptions{})
+	if err == nil || err.Code != "[requesting json]" {
+		t.Fatal("expected the canceled context to stop the request but got ", err)
+	}
+	if requests != 1 {
+		t.Fatal("expected only one attempt but got ", requests)
+	}
+	if c.ctx != nil {
+		t.Fatal("expected WithContext not to change the client")
+	}
+
+	// an already canceled context doesn't send anything
+	_, _, err = c.WithContext(ctx).TopHeadlines(TopHeadlinesOptions{})
+	if err == nil || requests != 1 {
+		t.Fatal("expected no request for a canceled context but got ", requests)
+	}
+}
+
+func TestWithContext_CancelBackoff(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var requests int
+	limiter := NewQuotaLimiter(10, time.Hour)
+	c := mockClient(Config{
+		Retry:   RetryPolicy{MaxRetries: 3, Backoff: time.Hour},
+		Limiter: limiter,
+	}, func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusServiceUnavailable}, nil
+	})
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, _, err := c.WithContext(ctx).TopHeadlines(TopHeadlinesOptions{})
+	if err == nil || err.Message != context.Canceled.Error() {
+		t.Fatal("expected the cancel to stop the backoff but got ", err)
+	}
+	if requests != 1 || limiter.Remaining() != 9 {
+		t.Fatalf("expected only one attempt but got %d (remaining quota %d)", requests, limiter.Remaining())
+	}
+}
@@ -0,0 +1,96 @@
+package news
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// acceptEncoding is sent with every request that doesn't already
+// have an Accept-Encoding header. Because it is set explicitly the
+// body is decompressed by decodeBody and not by the transport, so it
+// also works with transports that disabled the compression.
+const acceptEncoding = "gzip, deflate"
+
+// maxDrain is how much of an unread body is discarded before closing
+// it. Reading a body to the end allows the connection to be reused,
+// but it is not worth it for huge bodies.
+const maxDrain = 256 << 10
+
+// defaultTransport is shared by all clients without their own
+// HTTPClient, so the connections are reused across clients (for
+// example the default client of the package level functions and a
+// new one after SetDefault).
+var defaultTransport = newTransport()
+
+// newTransport returns a transport tuned for polling the same
+// host often. The default of http.Transport only keeps 2 idle
+// connections per host, which leads to a lot of new connections
+// (and sockets in TIME_WAIT) with concurrent requests.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// closeBody reads the rest of the body (up to maxDrain) and closes
+// it, so that the connection can go back into the pool.
+func closeBody(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(body, maxDrain))
+	body.Close()
+}
+
+// decodeBody returns a reader for the decompressed body of the
+// response. Unknown encodings are returned as they are.
+func decodeBody(resp *http.Response) (io.Reader, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if resp.Uncompressed {
+		// the transport already did it
+		encoding = ""
+	}
+
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		return newDeflateReader(resp.Body)
+	default:
+		return resp.Body, nil
+	}
+}
+
+// newDeflateReader handles both variants of "deflate": the correct
+// one with the zlib wrapper and the raw deflate some servers send.
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// a zlib header uses the method 8 and is a multiple of 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,140 @@
+package news
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const articlesJSON = `{"status":"ok","totalResults":1,"articles":[{"title":"compressed"}]}`
+
+func compress(t *testing.T, encoding string, data string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate"} {
+		body := compress(t, encoding, articlesJSON)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Accept-Encoding") != "gzip, deflate" {
+				t.Error("expected the Accept-Encoding header but got ", r.Header.Get("Accept-Encoding"))
+			}
+			w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+			w.Write(body)
+		}))
+
+		// a transport that doesn't decompress by itself
+		c := NewClient(Config{
+			BaseURL:    srv.URL,
+			HTTPClient: &http.Client{Transport: &http.Transport{DisableCompression: true}},
+		})
+		articles, _, err := c.Everything(EverythingOptions{})
+		srv.Close()
+
+		if err != nil {
+			t.Fatal(encoding, ": ", err)
+		}
+		if len(articles) != 1 || articles[0].Title != "compressed" {
+			t.Fatalf("%s: expected the decompressed article but got %+v", encoding, articles)
+		}
+	}
+}
+
+func TestCompression_Error(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write(compress(t, "gzip", `{"status":"error","code":"apiKeyInvalid","message":"invalid"}`))
+	}))
+	defer srv.Close()
+
+	_, _, err := NewClient(Config{BaseURL: srv.URL}).Everything(EverythingOptions{})
+	if err == nil || err.Code != "apiKeyInvalid" {
+		t.Fatal("expected the decompressed api error but got ", err)
+	}
+}
+
+// body remembers if it was read to the end and closed.
+type body struct {
+	io.Reader
+	closed bool
+	eof    bool
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *body) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestCloseBody(t *testing.T) {
+	t.Parallel()
+
+	responses := []struct {
+		status int
+		json   string
+	}{
+		{http.StatusOK, articlesJSON + "\n\n"},
+		{http.StatusOK, `{"status":"error","code":"x"}`},
+		{http.StatusOK, `not json at all`},
+		{http.StatusBadGateway, `<html>bad gateway</html>`},
+		{http.StatusTooManyRequests, `{"status":"error","code":"rateLimited"}`},
+	}
+
+	for _, r := range responses {
+		b := &body{Reader: strings.NewReader(r.json)}
+		c := mockClient(Config{}, func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: r.status, Body: b}, nil
+		})
+		c.Everything(EverythingOptions{})
+
+		if !b.closed || !b.eof {
+			t.Fatalf("expected the body for %q to be drained and closed (closed %v, eof %v)", r.json, b.closed, b.eof)
+		}
+	}
+}
+
+func TestDefaultTransport(t *testing.T) {
+	t.Parallel()
+
+	a := NewClient(Config{}).httpClient.(*http.Client)
+	b := NewClient(Config{}).httpClient.(*http.Client)
+	if a.Transport != b.Transport || a.Transport != defaultTransport {
+		t.Fatal("expected the clients to share the transport")
+	}
+	if defaultTransport.MaxIdleConnsPerHost < 2 {
+		t.Fatal("expected more idle connections per host")
+	}
+}
@@ -0,0 +1,41 @@
+package trends
+
+import "strings"
+
+// Stopwords are the words that are ignored, by language. Add a list to
+// support another language.
+var Stopwords = map[string]map[string]bool{
+	"en": words(`
+		a about above after again against all also am an and any are as at
+		be because been before being below between both but by can could
+		did do does doing down during each few for from further had has
+		have having he her here hers herself him himself his how i if in
+		into is it its itself just me more most my myself new no nor not
+		now of off on once only or other our ours ourselves out over own
+		said same says she should so some such than that the their theirs
+		them themselves then there these they this those through to too
+		under until up very was we were what when where which while who
+		whom why will with would you your yours yourself yourselves
+		amid told via vs year years`),
+	"de": words(`
+		aber alle allem allen aller alles als also am an ander andere
+		anderem anderen anderer anderes auch auf aus bei bin bis bist da
+		damit dann das dass dein deine dem den denn der des dich die dies
+		diese diesem diesen dieser dieses dir doch dort du durch ein eine
+		einem einen einer eines er es etwas euch euer für gegen gewesen
+		hab habe haben hat hatte hier hin hinter ich ihm ihn ihnen ihr ihre
+		im in ins ist jede jedem jeden jeder jedes jetzt kann kein keine
+		können man mehr mein meine mit muss nach nicht nichts noch nun nur
+		ob oder ohne sehr sein seine sich sie sind so soll sollen über um
+		und uns unser unter vom von vor war waren was weil welche wenn wer
+		werden wie wieder will wir wird wo wurde wurden zu zum zur zwischen
+		neue neuen sagt laut jahr jahren`),
+}
+
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		set[w] = true
+	}
+	return set
+}
@@ -0,0 +1,274 @@
+// Package trends finds the keywords of a set of articles and the
+// terms that are rising compared to an earlier set.
+//
+// The titles and descriptions are split into words, the stopwords of
+// the language are removed and the remaining words are combined into
+// n-grams ("interest rates"). Keywords ranks them with TF-IDF, Rising
+// compares two sets with the log-likelihood ratio.
+package trends
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// Term is a word or n-gram of the articles.
+type Term struct {
+	Text  string
+	Score float64
+
+	// Count is the number of occurrences in the articles,
+	// Baseline the number in the baseline of Rising.
+	Count    int
+	Baseline int
+
+	// Articles contains every article with the term.
+	Articles []news.Article
+}
+
+// Options change the extraction. The zero value is usable.
+type Options struct {
+	// Language selects the stopwords, for example "en" or "de".
+	// If it is empty the stopwords of every language are removed.
+	Language string
+
+	// MaxN is the longest n-gram. Default: 3
+	MaxN int
+
+	// MinCount is the number of articles a term needs to be
+	// reported. Default: 2
+	MinCount int
+
+	// Limit is the maximum number of terms. Default: 20
+	Limit int
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxN <= 0 {
+		o.MaxN = 3
+	}
+	if o.MinCount <= 0 {
+		o.MinCount = 2
+	}
+	if o.Limit <= 0 {
+		o.Limit = 20
+	}
+	return o
+}
+
+// counts are the terms of a set of articles.
+type counts struct {
+	articles []news.Article
+	total    int // number of terms
+	terms    map[string]*Term
+}
+
+func count(articles []news.Article, opt Options) *counts {
+	c := &counts{articles: articles, terms: make(map[string]*Term)}
+
+	for _, a := range articles {
+		seen := make(map[string]bool)
+		for _, text := range []string{a.Title, a.Description} {
+			for _, segment := range segments(text, opt.Language) {
+				for n := 1; n <= opt.MaxN; n++ {
+					for i := 0; i+n <= len(segment); i++ {
+						gram := strings.Join(segment[i:i+n], " ")
+
+						t := c.terms[gram]
+						if t == nil {
+							t = &Term{Text: gram}
+							c.terms[gram] = t
+						}
+						t.Count++
+						c.total++
+						if !seen[gram] {
+							seen[gram] = true
+							t.Articles = append(t.Articles, a)
+						}
+					}
+				}
+			}
+		}
+	}
+	return c
+}
+
+// Keywords ranks the terms of the articles by TF-IDF. The articles
+// are the documents, so a term that is in many articles gets a lower
+// weight per occurrence but still ranks high because of its count.
+func Keywords(articles []news.Article, opt Options) []Term {
+	opt = opt.withDefaults()
+	c := count(articles, opt)
+
+	var result []Term
+	for _, t := range c.terms {
+		if len(t.Articles) < opt.MinCount {
+			continue
+		}
+		idf := math.Log(1 + float64(len(articles))/float64(len(t.Articles)))
+		t.Score = float64(t.Count) * idf * weight(t.Text)
+		result = append(result, *t)
+	}
+	return rank(result, opt.Limit)
+}
+
+// Rising returns the terms that are more frequent in current than in
+// baseline, ranked by the log-likelihood ratio (Dunning's G²). Without
+// a baseline every term is new and they are ranked by their count.
+func Rising(current, baseline []news.Article, opt Options) []Term {
+	opt = opt.withDefaults()
+	cur := count(current, opt)
+	base := count(baseline, opt)
+
+	var result []Term
+	for text, t := range cur.terms {
+		if len(t.Articles) < opt.MinCount {
+			continue
+		}
+		if b := base.terms[text]; b != nil {
+			t.Baseline = b.Count
+		}
+
+		// relative frequency has to grow
+		if float64(t.Baseline)*float64(cur.total) >= float64(t.Count)*float64(base.total) && base.total > 0 {
+			continue
+		}
+		score := float64(t.Count)
+		if base.total > 0 {
+			score = logLikelihood(t.Count, t.Baseline, cur.total, base.total)
+		}
+		t.Score = score * weight(text)
+		result = append(result, *t)
+	}
+	return rank(result, opt.Limit)
+}
+
+// Split divides the articles into the ones published at or after
+// since (current) and the ones before it (baseline). Articles
+// without a valid date are left out. For "what is trending in the
+// last hour":
+//
+//	current, baseline := trends.Split(articles, time.Now().Add(-time.Hour))
+//	terms := trends.Rising(current, baseline, trends.Options{})
+func Split(articles []news.Article, since time.Time) (current, baseline []news.Article) {
+	for _, a := range articles {
+		published, err := time.Parse(time.RFC3339, a.PublishedAt)
+		if err != nil {
+			continue
+		}
+		if published.Before(since) {
+			baseline = append(baseline, a)
+		} else {
+			current = append(current, a)
+		}
+	}
+	return current, baseline
+}
+
+// logLikelihood is the G² statistic for a term that occurs a times
+// in c terms and b times in d terms.
+func logLikelihood(a, b, c, d int) float64 {
+	e1 := float64(c) * float64(a+b) / float64(c+d)
+	e2 := float64(d) * float64(a+b) / float64(c+d)
+
+	g := 0.0
+	if a > 0 {
+		g += float64(a) * math.Log(float64(a)/e1)
+	}
+	if b > 0 {
+		g += float64(b) * math.Log(float64(b)/e2)
+	}
+	return 2 * g
+}
+
+// weight prefers the longer n-grams a little, because they are
+// rarer than their words but more descriptive.
+func weight(text string) float64 {
+	return 1 + 0.5*float64(strings.Count(text, " "))
+}
+
+// rank sorts the terms and removes the words that only occur as
+// part of a longer term.
+func rank(terms []Term, limit int) []Term {
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Score != terms[j].Score {
+			return terms[i].Score > terms[j].Score
+		}
+		return terms[i].Text < terms[j].Text
+	})
+
+	var result []Term
+	for _, t := range terms {
+		if len(result) == limit {
+			break
+		}
+		if !partOf(t, terms) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func partOf(t Term, terms []Term) bool {
+	for _, longer := range terms {
+		if longer.Count == t.Count && len(longer.Text) > len(t.Text) &&
+			strings.Contains(" "+longer.Text+" ", " "+t.Text+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// segments splits the text into the runs of words between the
+// stopwords, so that an n-gram never spans a stopword.
+func segments(text, language string) [][]string {
+	var result [][]string
+	var current []string
+
+	for _, word := range tokenize(text) {
+		if isStopword(word, language) || len([]rune(word)) < 2 || isNumber(word) {
+			if len(current) > 0 {
+				result = append(result, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, word)
+	}
+	if len(current) > 0 {
+		result = append(result, current)
+	}
+	return result
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func isStopword(word, language string) bool {
+	if language != "" {
+		return Stopwords[language][word]
+	}
+	for _, list := range Stopwords {
+		if list[word] {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsNumber(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package trends
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+func articles(titles ...string) []news.Article {
+	var result []news.Article
+	for _, title := range titles {
+		result = append(result, news.Article{Title: title, URL: "https://example.com/" + title})
+	}
+	return result
+}
+
+func texts(terms []Term) []string {
+	var result []string
+	for _, t := range terms {
+		result = append(result, t.Text)
+	}
+	return result
+}
+
+func TestSegments(t *testing.T) {
+	t.Parallel()
+
+	result := segments("The Bank of England raises interest rates in 2024", "en")
+	expected := [][]string{{"bank"}, {"england", "raises", "interest", "rates"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %q but got %q", expected, result)
+	}
+
+	result = segments("Die Zinsen steigen über den Sommer", "de")
+	expected = [][]string{{"zinsen", "steigen"}, {"sommer"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %q but got %q", expected, result)
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	t.Parallel()
+
+	set := articles(
+		"Interest rates rise again",
+		"Why interest rates matter for your mortgage",
+		"Interest rates: what happens next",
+		"Football club wins the cup",
+		"Football fans celebrate",
+	)
+
+	terms := Keywords(set, Options{Language: "en", Limit: 2})
+	if got := texts(terms); !reflect.DeepEqual(got, []string{"interest rates", "football"}) {
+		t.Fatal("unexpected keywords ", got)
+	}
+	if terms[0].Count != 3 || len(terms[0].Articles) != 3 {
+		t.Fatalf("unexpected term %+v", terms[0])
+	}
+}
+
+func TestRising(t *testing.T) {
+	t.Parallel()
+
+	baseline := articles(
+		"Football club wins the cup",
+		"Football fans celebrate",
+		"Football season ends",
+		"Election campaign starts",
+		"Election polls tighten",
+	)
+	current := articles(
+		"Storm hits the coast",
+		"Storm warning for the weekend",
+		"Storm damage in the north",
+		"Football coach resigns",
+		"Football transfer news",
+	)
+
+	terms := Rising(current, baseline, Options{Language: "en"})
+	if len(terms) != 1 || terms[0].Text != "storm" || terms[0].Count != 3 || terms[0].Baseline != 0 {
+		t.Fatalf("expected only storm to rise but got %+v", texts(terms))
+	}
+}
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	set := []news.Article{
+		{Title: "old", PublishedAt: "2024-01-01T10:00:00Z"},
+		{Title: "new", PublishedAt: "2024-01-01T11:30:00Z"},
+		{Title: "no date"},
+	}
+	current, baseline := Split(set, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))
+	if len(current) != 1 || current[0].Title != "new" || len(baseline) != 1 || baseline[0].Title != "old" {
+		t.Fatalf("unexpected split %v %v", current, baseline)
+	}
+}
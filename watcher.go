@@ -0,0 +1,322 @@
+package news
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SeenStore keeps track of the articles a Watcher already delivered.
+// Articles are identified by their url. Implement it yourself to
+// persist the seen-set somewhere else (database, redis, ...).
+type SeenStore interface {
+	Seen(url string) (bool, error)
+	MarkSeen(url string) error
+}
+
+// MemoryStore is a SeenStore that only lives as long as the process.
+type MemoryStore struct {
+	mu   sync.Mutex
+	urls map[string]bool
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{urls: make(map[string]bool)}
+}
+
+// Seen reports wether the url was already marked as seen.
+func (s *MemoryStore) Seen(url string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.urls[url], nil
+}
+
+// MarkSeen remembers the url.
+func (s *MemoryStore) MarkSeen(url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.urls[url] = true
+	return nil
+}
+
+// FileStore is a SeenStore that appends every url to a file
+// (one url per line) so that a restarted Watcher does not
+// emit the same articles again.
+type FileStore struct {
+	mem  *MemoryStore
+	file *os.File
+}
+
+// NewFileStore opens (or creates) the file at path and loads
+// the urls that are already in it.
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	mem := NewMemoryStore()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			mem.urls[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &FileStore{mem: mem, file: file}, nil
+}
+
+// Seen reports wether the url was already marked as seen.
+func (s *FileStore) Seen(url string) (bool, error) {
+	return s.mem.Seen(url)
+}
+
+// MarkSeen remembers the url and writes it to the file.
+func (s *FileStore) MarkSeen(url string) error {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	if s.mem.urls[url] {
+		return nil
+	}
+	if _, err := s.file.WriteString(url + "\n"); err != nil {
+		return err
+	}
+	s.mem.urls[url] = true
+	return nil
+}
+
+// Close closes the underlying file.
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
+
+// Watcher re-runs a query on an interval and only delivers the
+// articles it hasn't seen before.
+type Watcher struct {
+	// Query is called on every poll. Use NewTopHeadlinesWatcher or
+	// NewEverythingWatcher to fill it from an options struct.
+	Query func() ([]Article, *ResponseInfo, *Exception)
+
+	// Interval is the minimum time between two polls. If the api
+	// returned a cached result the watcher waits until the cache
+	// expires instead. Default: 1 minute
+	Interval time.Duration
+
+	// Store keeps track of the seen articles. Default: MemoryStore
+	Store SeenStore
+
+	// OnError gets called if a poll failed. The watcher keeps
+	// running and tries again after the interval.
+	OnError func(err *Exception)
+
+	once sync.Once
+}
+
+// NewTopHeadlinesWatcher creates a watcher that polls TopHeadlines.
+func NewTopHeadlinesWatcher(opt TopHeadlinesOptions, interval time.Duration) *Watcher {
+	return &Watcher{
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			return TopHeadlines(opt)
+		},
+		Interval: interval,
+	}
+}
+
+// NewEverythingWatcher creates a watcher that polls Everything.
+func NewEverythingWatcher(opt EverythingOptions, interval time.Duration) *Watcher {
+	return &Watcher{
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			return Everything(opt)
+		},
+		Interval: interval,
+	}
+}
+
+// TopHeadlinesWatcher creates a watcher that polls TopHeadlines
+// with the settings of the client.
+func (c *Client) TopHeadlinesWatcher(opt TopHeadlinesOptions, interval time.Duration) *Watcher {
+	return &Watcher{
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			return c.TopHeadlines(opt)
+		},
+		Interval: interval,
+	}
+}
+
+// EverythingWatcher creates a watcher that polls Everything with
+// the settings of the client.
+func (c *Client) EverythingWatcher(opt EverythingOptions, interval time.Duration) *Watcher {
+	return &Watcher{
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			return c.Everything(opt)
+		},
+		Interval: interval,
+	}
+}
+
+func (w *Watcher) init() {
+	w.once.Do(func() {
+		if w.Interval <= 0 {
+			w.Interval = time.Minute
+		}
+		if w.Store == nil {
+			w.Store = NewMemoryStore()
+		}
+	})
+}
+
+// Poll runs the query once and returns the articles that were not
+// seen before. They are marked as seen before returning, so an
+// article is lost if the caller fails to handle it (at-most-once).
+// Use PollEach to mark them after they were handled.
+func (w *Watcher) Poll() ([]Article, *ResponseInfo, *Exception) {
+	var fresh []Article
+	info, err := w.PollEach(func(a Article) error {
+		fresh = append(fresh, a)
+		return nil
+	})
+	return fresh, info, err
+}
+
+// PollEach runs the query once and calls fn for every article that
+// was not seen before. An article is marked as seen after fn returned
+// nil. If fn returns an error (or panics) the article stays new and is
+// passed to fn again with the next poll.
+func (w *Watcher) PollEach(fn func(Article) error) (*ResponseInfo, *Exception) {
+	w.init()
+
+	articles, info, err := w.Query()
+	if err != nil {
+		return info, err
+	}
+
+	for _, article := range articles {
+		if article.URL == "" {
+			continue
+		}
+
+		seen, err := w.seen(article)
+		if err != nil {
+			return info, &Exception{
+				Code:    "[seen store]",
+				Message: err.Error(),
+			}
+		}
+		if seen {
+			continue
+		}
+
+		if fn(article) != nil {
+			continue
+		}
+		if err := w.Store.MarkSeen(article.CanonicalURL()); err != nil {
+			return info, &Exception{
+				Code:    "[seen store]",
+				Message: err.Error(),
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// seen checks the canonical url of the article. Stores that were
+// filled before the urls were normalized contain the raw url, so
+// that is checked too.
+func (w *Watcher) seen(article Article) (bool, error) {
+	seen, err := w.Store.Seen(article.CanonicalURL())
+	if err != nil || seen {
+		return seen, err
+	}
+	return w.Store.Seen(article.URL)
+}
+
+// Run polls until stop is closed and calls fn for every new article.
+// An article is marked as seen after fn returned.
+func (w *Watcher) Run(stop <-chan struct{}, fn func(Article)) {
+	w.RunEach(stop, func(a Article) error {
+		fn(a)
+		return nil
+	})
+}
+
+// RunEach polls until stop is closed and calls fn for every new
+// article. Like with PollEach an article is only marked as seen if fn
+// returned nil, otherwise it is passed again with the next poll.
+func (w *Watcher) RunEach(stop <-chan struct{}, fn func(Article) error) {
+	w.init()
+
+	for {
+		info, err := w.PollEach(fn)
+		if err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+
+		timer := time.NewTimer(w.nextInterval(info))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// errStopped keeps the articles that weren't received before the
+// stop new.
+var errStopped = errors.New("the watcher was stopped")
+
+// Start runs the watcher in the background and sends the new
+// articles to the returned channel. The channel gets closed
+// once stop is closed. An article is marked as seen once it was
+// received from the channel.
+func (w *Watcher) Start(stop <-chan struct{}) <-chan Article {
+	ch := make(chan Article)
+
+	go func() {
+		defer close(ch)
+		w.RunEach(stop, func(a Article) error {
+			select {
+			case ch <- a:
+				return nil
+			case <-stop:
+				return errStopped
+			}
+		})
+	}()
+
+	return ch
+}
+
+// nextInterval returns how long to wait before the next poll. If the
+// result was cached, polling again before the cache expires would
+// only return the same articles.
+func (w *Watcher) nextInterval(info *ResponseInfo) time.Duration {
+	if info == nil || !info.Cached {
+		return w.Interval
+	}
+
+	var remaining time.Duration
+	if seconds, err := strconv.Atoi(info.Remaining); err == nil {
+		remaining = time.Duration(seconds) * time.Second
+	} else if expires, err := http.ParseTime(info.Expires); err == nil {
+		remaining = time.Until(expires)
+	}
+
+	if remaining > w.Interval {
+		return remaining
+	}
+	return w.Interval
+}
@@ -0,0 +1,215 @@
+package news
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatcher_OnlyNewArticles(t *testing.T) {
+	t.Parallel()
+
+	polls := [][]Article{
+		{{URL: "a"}, {URL: "b"}},
+		{{URL: "b"}, {URL: "c"}},
+	}
+
+	i := 0
+	w := &Watcher{
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			articles := polls[i]
+			i++
+			return articles, &ResponseInfo{}, nil
+		},
+	}
+
+	first, _, err := w.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 {
+		t.Fatal("expected 2 new articles but got ", len(first))
+	}
+
+	second, _, err := w.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 1 || second[0].URL != "c" {
+		t.Fatalf("expected only article 'c' but got %+v", second)
+	}
+}
+
+func TestWatcher_FileStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "seen.txt")
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkSeen("a"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// a restarted watcher should still know about 'a'
+	store, err = NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	w := &Watcher{
+		Store: store,
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			return []Article{{URL: "a"}, {URL: "b"}}, &ResponseInfo{}, nil
+		},
+	}
+	articles, _, _ := w.Poll()
+	if len(articles) != 1 || articles[0].URL != "b" {
+		t.Fatalf("expected only article 'b' but got %+v", articles)
+	}
+}
+
+func TestWatcher_NextInterval(t *testing.T) {
+	t.Parallel()
+
+	w := &Watcher{Interval: time.Minute}
+
+	if d := w.nextInterval(nil); d != time.Minute {
+		t.Fatal("expected the default interval but got ", d)
+	}
+	if d := w.nextInterval(&ResponseInfo{Cached: false, Remaining: "300"}); d != time.Minute {
+		t.Fatal("a fresh result should not change the interval but got ", d)
+	}
+	if d := w.nextInterval(&ResponseInfo{Cached: true, Remaining: "300"}); d != 5*time.Minute {
+		t.Fatal("expected to wait for the cache to expire but got ", d)
+	}
+	if d := w.nextInterval(&ResponseInfo{Cached: true, Remaining: "10"}); d != time.Minute {
+		t.Fatal("expected the interval as the lower bound but got ", d)
+	}
+}
+
+func TestWatcher_Start(t *testing.T) {
+	t.Parallel()
+
+	w := &Watcher{
+		Interval: time.Millisecond,
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			return []Article{{URL: "a"}, {URL: "b"}}, &ResponseInfo{}, nil
+		},
+	}
+
+	stop := make(chan struct{})
+	ch := w.Start(stop)
+
+	if a := <-ch; a.URL != "a" {
+		t.Fatal("expected article 'a' but got ", a.URL)
+	}
+	if a := <-ch; a.URL != "b" {
+		t.Fatal("expected article 'b' but got ", a.URL)
+	}
+	close(stop)
+
+	for a := range ch {
+		t.Fatal("did not expect another article but got ", a.URL)
+	}
+}
+
+func TestWatcher_CanonicalURL(t *testing.T) {
+	t.Parallel()
+
+	// a store from before the urls were normalized
+	store := NewMemoryStore()
+	store.MarkSeen("http://www.example.com/1")
+
+	w := &Watcher{
+		Store: store,
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			return []Article{
+				{URL: "http://www.example.com/1"},
+				{URL: "https://example.com/2"},
+				{URL: "https://m.example.com/2?utm_source=feed"},
+			}, &ResponseInfo{}, nil
+		},
+	}
+	articles, _, _ := w.Poll()
+	if len(articles) != 1 || articles[0].URL != "https://example.com/2" {
+		t.Fatalf("expected only the first version of article 2 but got %+v", articles)
+	}
+}
+
+func TestWatcher_PollEach(t *testing.T) {
+	t.Parallel()
+
+	w := &Watcher{
+		Query: func() ([]Article, *ResponseInfo, *Exception) {
+			return []Article{{URL: "a"}, {URL: "b"}}, &ResponseInfo{}, nil
+		},
+	}
+
+	// 'a' failed and is passed again
+	var urls []string
+	w.PollEach(func(a Article) error {
+		urls = append(urls, a.URL)
+		if a.URL == "a" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	w.PollEach(func(a Article) error {
+		urls = append(urls, a.URL)
+		return nil
+	})
+	if strings.Join(urls, " ") != "a b a" {
+		t.Fatal("expected the failed article to be passed again but got ", urls)
+	}
+}
+
+func TestWatcher_StartStopped(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	query := func() ([]Article, *ResponseInfo, *Exception) {
+		return []Article{{URL: "a"}, {URL: "b"}}, &ResponseInfo{}, nil
+	}
+
+	stop := make(chan struct{})
+	ch := (&Watcher{Interval: time.Hour, Store: store, Query: query}).Start(stop)
+	if a := <-ch; a.URL != "a" {
+		t.Fatal("expected article 'a' but got ", a.URL)
+	}
+	close(stop)
+	for range ch {
+	}
+
+	// 'b' was never received, so a restarted watcher sends it
+	articles, _, _ := (&Watcher{Store: store, Query: query}).Poll()
+	if len(articles) != 1 || articles[0].URL != "b" {
+		t.Fatalf("expected only article 'b' but got %+v", articles)
+	}
+}
+
+func TestClient_Watcher(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apiKey") != "client-key" {
+			t.Error("expected the key of the client")
+		}
+		w.Write([]byte(`{"status":"ok","totalResults":1,"articles":[{"url":"https://example.com/a"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(Config{BaseURL: srv.URL, APIKey: "client-key"})
+	articles, _, err := c.TopHeadlinesWatcher(TopHeadlinesOptions{Country: "de"}, time.Minute).Poll()
+	if err != nil || len(articles) != 1 {
+		t.Fatalf("unexpected articles %+v %v", articles, err)
+	}
+}
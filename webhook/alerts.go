@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"errors"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+	"github.com/JohannesKaufmann/News-API-go/alerts"
+)
+
+// AlertSink is an alerts.Sink that posts the canonical article of
+// every alert with the Sender, so the requests are signed and
+// retried. The receiver gets a Batch with one article.
+type AlertSink struct {
+	Sender *Sender
+}
+
+// Send posts the article of the alert. A failed delivery that is in
+// the retry queue of the Sender counts as sent, otherwise the engine
+// would send the alert again. Only a delivery that went to the dead
+// letter file is an error.
+func (s AlertSink) Send(a alerts.Alert) error {
+	err := s.Sender.Send([]news.Article{a.Article})
+	if errors.Is(err, ErrQueued) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,374 @@
+// Package webhook delivers articles to webhooks.
+//
+// The articles are posted as json batches. Every request is signed
+// with HMAC-SHA256 and carries an idempotency key, so a receiver can
+// check that the request is from us (see Verifier) and ignore a batch
+// that it already got. Failed deliveries are retried with a growing
+// backoff and are written to a dead letter file if they keep failing.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+)
+
+// The headers of every request.
+const (
+	HeaderSignature      = "X-News-Signature"
+	HeaderTimestamp      = "X-News-Timestamp"
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// ErrNoSecret is returned for a Sender without a Secret. Unsigned
+// requests can't be told apart from forged ones.
+var ErrNoSecret = errors.New("webhook: the secret is empty")
+
+// ErrQueued matches (with errors.Is) the errors of Send for the
+// deliveries that failed but are in the retry queue.
+var ErrQueued = errors.New("webhook: queued for a retry")
+
+// queuedError is the error of a delivery in the retry queue.
+type queuedError struct {
+	err error
+}
+
+func (e *queuedError) Error() string        { return e.err.Error() }
+func (e *queuedError) Unwrap() error        { return e.err }
+func (e *queuedError) Is(target error) bool { return target == ErrQueued }
+
+// Batch is the body of a request.
+type Batch struct {
+	// ID is the idempotency key of the batch. It only depends on
+	// the urls of the articles, so a retry has the same ID.
+	ID       string         `json:"id"`
+	Articles []news.Article `json:"articles"`
+}
+
+// DeadLetter is a delivery that failed MaxAttempts times. It is
+// written as one json line to the dead letter file.
+type DeadLetter struct {
+	URL      string    `json:"url"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	Time     time.Time `json:"time"`
+	Batch    Batch     `json:"batch"`
+}
+
+// Sender posts articles to the URLs. Create it with NewSender or
+// set at least the URLs and the Secret.
+type Sender struct {
+	URLs []string
+
+	// Secret is the key for the signatures. It has to be the
+	// same as the one of the Verifier of the receiver.
+	Secret []byte
+
+	// Client is used for the requests. Default: a http.Client
+	// with a timeout of 10 seconds
+	Client *http.Client
+
+	// BatchSize is the maximum number of articles per request.
+	// Default: 50
+	BatchSize int
+
+	// MaxAttempts is the number of attempts before a delivery
+	// goes to the dead letter file. Default: 5
+	MaxAttempts int
+
+	// Backoff is the wait after the first failed attempt. It
+	// doubles with every further attempt up to MaxBackoff.
+	// Default: 30 seconds
+	Backoff time.Duration
+
+	// MaxBackoff is the longest wait between two attempts.
+	// Default: 1 hour
+	MaxBackoff time.Duration
+
+	// MaxPending is the maximum number of deliveries in the
+	// retry queue. A failed delivery that doesn't fit goes to
+	// the dead letter file right away. Default: 1000
+	MaxPending int
+
+	// DeadLetterPath is the file for the deliveries that failed
+	// MaxAttempts times. If it is empty they are dropped.
+	DeadLetterPath string
+
+	// OnError gets called for every failed attempt.
+	OnError func(url string, err error)
+
+	// now can be replaced in tests.
+	now func() time.Time
+
+	once  sync.Once
+	mu    sync.Mutex
+	queue []*delivery
+}
+
+// delivery is a batch for one url.
+type delivery struct {
+	url      string
+	batch    Batch
+	body     []byte
+	attempts int
+	next     time.Time
+	err      error
+}
+
+// NewSender creates a sender that signs the requests with the
+// secret. It returns ErrNoSecret if the secret is empty.
+func NewSender(secret []byte, urls ...string) (*Sender, error) {
+	if len(secret) == 0 {
+		return nil, ErrNoSecret
+	}
+	return &Sender{URLs: urls, Secret: secret}, nil
+}
+
+func (s *Sender) init() {
+	s.once.Do(func() {
+		if s.Client == nil {
+			s.Client = &http.Client{Timeout: 10 * time.Second}
+		}
+		if s.BatchSize <= 0 {
+			s.BatchSize = 50
+		}
+		if s.MaxAttempts <= 0 {
+			s.MaxAttempts = 5
+		}
+		if s.Backoff <= 0 {
+			s.Backoff = 30 * time.Second
+		}
+		if s.MaxBackoff <= 0 {
+			s.MaxBackoff = time.Hour
+		}
+		if s.MaxPending <= 0 {
+			s.MaxPending = 1000
+		}
+		if s.now == nil {
+			s.now = time.Now
+		}
+	})
+}
+
+// Send posts the articles in batches to every url. The failed
+// deliveries are put into the retry queue, the returned error is
+// the first of them. It matches ErrQueued if every failed delivery
+// is in the queue, otherwise it is the error of a delivery that
+// went to the dead letter file.
+func (s *Sender) Send(articles []news.Article) error {
+	s.init()
+	if len(s.Secret) == 0 {
+		return ErrNoSecret
+	}
+
+	var first, lost error
+	for start := 0; start < len(articles); start += s.BatchSize {
+		end := start + s.BatchSize
+		if end > len(articles) {
+			end = len(articles)
+		}
+		batch := Batch{
+			ID:       IdempotencyKey(articles[start:end]),
+			Articles: articles[start:end],
+		}
+		body, err := json.Marshal(batch)
+		if err != nil {
+			return err
+		}
+
+		for _, url := range s.URLs {
+			d := &delivery{url: url, batch: batch, body: body}
+			err := s.attempt(d)
+			if err != nil && first == nil {
+				first = err
+			}
+			if err != nil && lost == nil && !errors.Is(err, ErrQueued) {
+				lost = err
+			}
+		}
+	}
+	if lost != nil {
+		return lost
+	}
+	return first
+}
+
+// Retry attempts the deliveries of the queue that are due.
+func (s *Sender) Retry() {
+	s.init()
+
+	now := s.now()
+	s.mu.Lock()
+	var due []*delivery
+	rest := s.queue[:0]
+	for _, d := range s.queue {
+		if d.next.After(now) {
+			rest = append(rest, d)
+		} else {
+			due = append(due, d)
+		}
+	}
+	s.queue = rest
+	s.mu.Unlock()
+
+	for _, d := range due {
+		s.attempt(d)
+	}
+}
+
+// Pending returns the number of deliveries in the retry queue.
+func (s *Sender) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// Run calls Retry every interval until stop is closed.
+func (s *Sender) Run(stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.Retry()
+		}
+	}
+}
+
+// attempt posts the delivery once. If it fails, it goes back into
+// the queue (and the error matches ErrQueued) or into the dead
+// letter file.
+func (s *Sender) attempt(d *delivery) error {
+	d.attempts++
+	d.err = s.post(d)
+	if d.err == nil {
+		return nil
+	}
+	if s.OnError != nil {
+		s.OnError(d.url, d.err)
+	}
+
+	queued := false
+	if d.attempts < s.MaxAttempts {
+		d.next = s.now().Add(s.backoff(d.attempts))
+		s.mu.Lock()
+		if len(s.queue) < s.MaxPending {
+			s.queue = append(s.queue, d)
+			queued = true
+		}
+		s.mu.Unlock()
+	}
+	if queued {
+		return &queuedError{d.err}
+	}
+	if err := s.deadLetter(d); err != nil && s.OnError != nil {
+		s.OnError(d.url, err)
+	}
+	return d.err
+}
+
+// backoff is the wait after the attempt, Backoff doubled for every
+// earlier attempt up to MaxBackoff.
+func (s *Sender) backoff(attempts int) time.Duration {
+	backoff := s.Backoff
+	for i := 1; i < attempts && backoff < s.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > s.MaxBackoff {
+		return s.MaxBackoff
+	}
+	return backoff
+}
+
+func (s *Sender) post(d *delivery) error {
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+	// the signature gets a new timestamp on every attempt, so
+	// that the receiver can reject old requests
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, d.body))
+	req.Header.Set(HeaderIdempotencyKey, d.batch.ID)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	// reading the body lets the client reuse the connection
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *Sender) deadLetter(d *delivery) error {
+	if s.DeadLetterPath == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.DeadLetterPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(file).Encode(DeadLetter{
+		URL:      d.url,
+		Attempts: d.attempts,
+		Error:    d.err.Error(),
+		Time:     s.now(),
+		Batch:    d.batch,
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// IdempotencyKey is derived from the canonical urls of the articles.
+// A single article has the same key in every batch.
+func IdempotencyKey(articles []news.Article) string {
+	if len(articles) == 1 {
+		return articles[0].ID()
+	}
+
+	h := sha256.New()
+	for _, a := range articles {
+		h.Write([]byte(a.CanonicalURL()))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Sign returns the signature of the body, "sha256=" and the hex
+// encoded HMAC-SHA256 of the timestamp, a dot and the body.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	news "github.com/JohannesKaufmann/News-API-go"
+	"github.com/JohannesKaufmann/News-API-go/alerts"
+)
+
+var secret = []byte("shared-secret")
+
+func testArticles(n int) []news.Article {
+	var articles []news.Article
+	for i := 0; i < n; i++ {
+		articles = append(articles, news.Article{
+			Title: fmt.Sprintf("Article %d", i),
+			URL:   fmt.Sprintf("https://example.com/%d?utm_source=feed", i),
+		})
+	}
+	return articles
+}
+
+// receiver is a webhook that fails the first requests.
+type receiver struct {
+	mu      sync.Mutex
+	fails   int
+	batches []Batch
+	keys    []string
+}
+
+func (r *receiver) server(t *testing.T) *httptest.Server {
+	verify := Verifier{Secret: secret}.Handler(func(b Batch) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.fails > 0 {
+			r.fails--
+			return fmt.Errorf("not now")
+		}
+		r.batches = append(r.batches, b)
+		return nil
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.keys = append(r.keys, req.Header.Get(HeaderIdempotencyKey))
+		r.mu.Unlock()
+		verify.ServeHTTP(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSender_Batches(t *testing.T) {
+	t.Parallel()
+
+	r := &receiver{}
+	srv := r.server(t)
+
+	s := &Sender{URLs: []string{srv.URL}, Secret: secret, BatchSize: 2}
+	if err := s.Send(testArticles(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.batches) != 3 || len(r.batches[2].Articles) != 1 {
+		t.Fatalf("expected 3 batches but got %+v", r.batches)
+	}
+	// a single article has the id of the article as the key
+	last := r.batches[2]
+	if last.ID != last.Articles[0].ID() || r.keys[2] != last.ID {
+		t.Fatalf("unexpected idempotency key %q", last.ID)
+	}
+	if s.Pending() != 0 {
+		t.Fatal("expected an empty queue")
+	}
+}
+
+func TestSender_Retry(t *testing.T) {
+	t.Parallel()
+
+	r := &receiver{fails: 2}
+	srv := r.server(t)
+
+	// the receiver checks the timestamp against the real time
+	now := time.Now()
+	s := &Sender{URLs: []string{srv.URL}, Secret: secret, Backoff: time.Minute}
+	s.now = func() time.Time { return now }
+
+	if err := s.Send(testArticles(1)); !errors.Is(err, ErrQueued) {
+		t.Fatal("expected the queued error of the first attempt but got ", err)
+	}
+	if s.Pending() != 1 {
+		t.Fatal("expected the delivery in the queue")
+	}
+
+	// not due yet
+	s.Retry()
+	if len(r.keys) != 1 {
+		t.Fatal("expected no attempt before the backoff")
+	}
+
+	now = now.Add(time.Minute)
+	s.Retry()
+	if len(r.keys) != 2 || s.Pending() != 1 {
+		t.Fatal("expected a second failed attempt")
+	}
+
+	// the backoff doubled
+	now = now.Add(time.Minute)
+	s.Retry()
+	if len(r.keys) != 2 {
+		t.Fatal("expected the doubled backoff")
+	}
+	now = now.Add(time.Minute)
+	s.Retry()
+
+	if len(r.batches) != 1 || s.Pending() != 0 {
+		t.Fatal("expected the delivery after the retries")
+	}
+	if r.keys[0] != r.keys[1] || r.keys[1] != r.keys[2] {
+		t.Fatal("expected the same idempotency key for every attempt ", r.keys)
+	}
+}
+
+func TestSender_DeadLetter(t *testing.T) {
+	t.Parallel()
+
+	r := &receiver{fails: 100}
+	srv := r.server(t)
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+
+	now := time.Now()
+	var errors int
+	s := &Sender{
+		URLs:           []string{srv.URL},
+		Secret:         secret,
+		MaxAttempts:    3,
+		Backoff:        time.Second,
+		DeadLetterPath: path,
+		OnError:        func(string, error) { errors++ },
+	}
+	s.now = func() time.Time { return now }
+
+	s.Send(testArticles(2))
+	for i := 0; i < 5; i++ {
+		now = now.Add(10 * time.Second)
+		s.Retry()
+	}
+	if len(r.keys) != 3 || errors != 3 || s.Pending() != 0 {
+		t.Fatalf("expected 3 attempts but got %d (errors %d, pending %d)", len(r.keys), errors, s.Pending())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("expected a dead letter")
+	}
+	var dead DeadLetter
+	if err := json.Unmarshal(scanner.Bytes(), &dead); err != nil {
+		t.Fatal(err)
+	}
+	if dead.URL != srv.URL || dead.Attempts != 3 || len(dead.Batch.Articles) != 2 || dead.Error != "webhook: status code 500" {
+		t.Fatalf("unexpected dead letter %+v", dead)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	a := testArticles(2)
+	b := testArticles(2)
+	b[0].URL = "https://example.com/0"
+
+	if IdempotencyKey(a) != IdempotencyKey(b) {
+		t.Fatal("expected the same key for the same canonical urls")
+	}
+	if IdempotencyKey(a) == IdempotencyKey(a[:1]) {
+		t.Fatal("expected different keys for different batches")
+	}
+}
+
+func TestNewSender(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewSender(nil, "https://example.com"); err != ErrNoSecret {
+		t.Fatal("expected an error for the empty secret but got ", err)
+	}
+	if err := (&Sender{URLs: []string{"https://example.com"}}).Send(testArticles(1)); err != ErrNoSecret {
+		t.Fatal("expected no unsigned request but got ", err)
+	}
+	s, err := NewSender(secret, "https://example.com")
+	if err != nil || len(s.URLs) != 1 {
+		t.Fatal("unexpected sender ", s, err)
+	}
+}
+
+func TestSender_MaxPending(t *testing.T) {
+	t.Parallel()
+
+	r := &receiver{fails: 100}
+	srv := r.server(t)
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+
+	s := &Sender{
+		URLs:           []string{srv.URL},
+		Secret:         secret,
+		BatchSize:      1,
+		MaxPending:     2,
+		DeadLetterPath: path,
+	}
+	s.Send(testArticles(3))
+	if s.Pending() != 2 {
+		t.Fatal("expected a full queue but got ", s.Pending())
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("expected the delivery that didn't fit in the dead letter file: ", err)
+	}
+	var dead DeadLetter
+	if err := json.Unmarshal(data, &dead); err != nil || dead.Attempts != 1 {
+		t.Fatalf("unexpected dead letter %+v (%v)", dead, err)
+	}
+}
+
+func TestAlertSink(t *testing.T) {
+	t.Parallel()
+
+	r := &receiver{}
+	srv := r.server(t)
+
+	var sink alerts.Sink = AlertSink{Sender: &Sender{URLs: []string{srv.URL}, Secret: secret}}
+	if err := sink.Send(alerts.Alert{Rule: "company", Article: testArticles(1)[0]}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.batches) != 1 || r.batches[0].Articles[0].Title != "Article 0" {
+		t.Fatalf("unexpected batches %+v", r.batches)
+	}
+
+	// the sender retries a queued delivery, so the alert counts as sent
+	r.mu.Lock()
+	r.fails = 1
+	r.mu.Unlock()
+	if err := sink.Send(alerts.Alert{Rule: "company", Article: testArticles(2)[1]}); err != nil {
+		t.Fatal("expected no error for a queued delivery but got ", err)
+	}
+	lost := AlertSink{Sender: &Sender{URLs: []string{srv.URL}, Secret: secret, MaxAttempts: 1}}
+	r.mu.Lock()
+	r.fails = 1
+	r.mu.Unlock()
+	if err := lost.Send(alerts.Alert{Rule: "company", Article: testArticles(1)[0]}); err == nil || errors.Is(err, ErrQueued) {
+		t.Fatal("expected the error of a lost delivery but got ", err)
+	}
+}
+
+func TestSender_MaxBackoff(t *testing.T) {
+	t.Parallel()
+
+	s := &Sender{Backoff: time.Minute, MaxBackoff: 10 * time.Minute}
+	s.init()
+	for attempts, expected := range map[int]time.Duration{
+		1:   time.Minute,
+		3:   4 * time.Minute,
+		5:   10 * time.Minute,
+		100: 10 * time.Minute,
+	} {
+		if d := s.backoff(attempts); d != expected {
+			t.Fatalf("attempt %d: expected %v but got %v", attempts, expected, d)
+		}
+	}
+}
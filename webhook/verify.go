@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The errors of the Verifier.
+var (
+	ErrSignature      = errors.New("webhook: invalid signature")
+	ErrExpired        = errors.New("webhook: the timestamp is too old")
+	ErrTooLarge       = errors.New("webhook: the body is too large")
+	ErrIdempotencyKey = errors.New("webhook: the idempotency key is not the id of the batch")
+)
+
+// Verifier checks the requests of a Sender on the receiving side.
+// Without a Secret every request is rejected.
+type Verifier struct {
+	Secret []byte
+
+	// MaxAge is the maximum difference between the timestamp of
+	// the request and now. Default: 5 minutes
+	MaxAge time.Duration
+
+	// MaxBodySize limits the body. Default: 16 MiB
+	MaxBodySize int64
+
+	// now can be replaced in tests.
+	now func() time.Time
+}
+
+// Verify checks the signature and the timestamp of the request and
+// returns the batch.
+func (v Verifier) Verify(r *http.Request) (Batch, error) {
+	maxBody := v.MaxBodySize
+	if maxBody <= 0 {
+		maxBody = 16 << 20
+	}
+	// one more byte tells a body that was cut off
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBody+1))
+	if err != nil {
+		return Batch{}, err
+	}
+	if int64(len(body)) > maxBody {
+		return Batch{}, ErrTooLarge
+	}
+
+	if len(v.Secret) == 0 {
+		return Batch{}, ErrSignature
+	}
+	timestamp := r.Header.Get(HeaderTimestamp)
+	expected := Sign(v.Secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(HeaderSignature))) {
+		return Batch{}, ErrSignature
+	}
+
+	// the timestamp is part of the signature, so it can be
+	// trusted after the signature was checked
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Batch{}, ErrSignature
+	}
+	maxAge := v.MaxAge
+	if maxAge <= 0 {
+		maxAge = 5 * time.Minute
+	}
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	age := now().Sub(time.Unix(sec, 0))
+	if age > maxAge || age < -maxAge {
+		return Batch{}, ErrExpired
+	}
+
+	var batch Batch
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return Batch{}, err
+	}
+	if batch.ID != r.Header.Get(HeaderIdempotencyKey) {
+		return Batch{}, ErrIdempotencyKey
+	}
+	return batch, nil
+}
+
+// Handler returns a http.Handler that verifies the requests and
+// calls fn with the batch. Requests with an invalid signature get
+// a 401, a too large body a 413, other invalid requests a 400 and
+// an error of fn a 500 so that the sender tries again.
+func (v Verifier) Handler(fn func(Batch) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		batch, err := v.Verify(r)
+		if err == ErrSignature || err == ErrExpired {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err == ErrTooLarge {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := fn(batch); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifier(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	body := `{"id":"1","articles":[{"title":"A"}]}`
+
+	tests := []struct {
+		name     string
+		key      []byte
+		sent     time.Time
+		body     string
+		id       string
+		expected error
+	}{
+		{"valid", secret, now, body, "1", nil},
+		{"wrong secret", []byte("other"), now, body, "1", ErrSignature},
+		{"changed body", secret, now, `{"id":"2"}`, "1", ErrSignature},
+		{"too old", secret, now.Add(-time.Hour), body, "1", ErrExpired},
+		{"other key", secret, now, body, "2", ErrIdempotencyKey},
+		{"too large", secret, now, body + strings.Repeat(" ", 64), "1", ErrTooLarge},
+	}
+	for _, test := range tests {
+		timestamp := strconv.FormatInt(test.sent.Unix(), 10)
+		req := httptest.NewRequest("POST", "/", bytes.NewReader([]byte(test.body)))
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(test.key, timestamp, []byte(body)))
+		req.Header.Set(HeaderIdempotencyKey, test.id)
+
+		v := Verifier{Secret: secret, MaxBodySize: 64, now: func() time.Time { return now }}
+		batch, err := v.Verify(req)
+		if err != test.expected {
+			t.Errorf("%s: expected %v but got %v", test.name, test.expected, err)
+		}
+		if err == nil && batch.Articles[0].Title != "A" {
+			t.Errorf("%s: unexpected batch %+v", test.name, batch)
+		}
+	}
+}
+
+func TestVerifier_Handler(t *testing.T) {
+	t.Parallel()
+
+	h := Verifier{Secret: secret}.Handler(func(Batch) error { return nil })
+
+	req := httptest.NewRequest("POST", "/", bytes.NewReader([]byte(`{}`)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != 401 {
+		t.Fatal("expected 401 for a request without signature but got ", rec.Code)
+	}
+
+	h = Verifier{Secret: secret, MaxBodySize: 8}.Handler(func(Batch) error { return nil })
+	req = httptest.NewRequest("POST", "/", bytes.NewReader([]byte(`{"id":"123456789"}`)))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != 413 {
+		t.Fatal("expected 413 for a large body but got ", rec.Code)
+	}
+}
+
+func TestVerifier_NoSecret(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"id":"1"}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(nil, timestamp, body))
+	req.Header.Set(HeaderIdempotencyKey, "1")
+
+	if _, err := (Verifier{}).Verify(req); err != ErrSignature {
+		t.Fatal("expected a request signed without a secret to be rejected but got ", err)
+	}
+}